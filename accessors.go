@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Identifies an unexported struct field by its owner type and Go field name.
+type accessorField struct {
+	owner reflect.Type
+	field string
+}
+
+// Exposes the unexported field of the owner struct through an exported method
+// with a value receiver that returns the value of the field:
+//
+//	type Account struct {
+//		id int `graphql:"type=ID"`
+//	}
+//
+//	func (a Account) ID() int { return a.id }
+//
+//	WithAccessor(reflect.TypeOf(Account{}), "id", "ID")
+//
+// Reflection can read the type and the tag of an unexported field, but not
+// its value. So the field is built from its declared type and tag like an
+// exported field, while its value is read by calling the method, which
+// must return the type of the field, optionally followed by an error.
+// The method isn't exposed as a separate field. Unexported fields
+// without an accessor are skipped.
+func WithAccessor(owner reflect.Type, field, method string) Option {
+	return func(o *options) {
+		if o.accessors == nil {
+			o.accessors = map[accessorField]string{}
+		}
+		o.accessors[accessorField{owner: owner, field: field}] = method
+	}
+}
+
+// Returns the name of the accessor method of an unexported field of the owner
+// struct after validating its signature, or false if no accessor is registered.
+func (o *options) accessor(owner reflect.Type, field reflect.StructField) (string, bool, error) {
+	name, ok := o.accessors[accessorField{owner: owner, field: field.Name}]
+	if !ok {
+		return "", false, nil
+	}
+
+	if field.IsExported() {
+		return "", false, fmt.Errorf("field %s of %s is exported and can't have an accessor", field.Name, owner.Name())
+	}
+
+	method, ok := owner.MethodByName(name)
+	if !ok {
+		return "", false, fmt.Errorf("accessor %s of field %s doesn't exist on %s or has a pointer receiver", name, field.Name, owner.Name())
+	}
+
+	numOut := method.Type.NumOut()
+	if method.Type.NumIn() != 1 || numOut == 0 || numOut > 2 || numOut == 2 && method.Type.Out(1) != typeError || method.Type.Out(0) != field.Type {
+		return "", false, fmt.Errorf("accessor %s of field %s of %s must take no parameters and return %s, optionally followed by an error", name, field.Name, owner.Name(), field.Type)
+	}
+	return name, true, nil
+}
+
+// Returns the value of the field of the struct value r. Unexported
+// fields are read through their accessor, see WithAccessor. The value
+// is invalid if the field is promoted through a nil embedded pointer.
+func (o *options) fieldValue(r reflect.Value, field reflect.StructField) (reflect.Value, error) {
+	if field.IsExported() {
+		// Promoted fields are read along their index, see reflect.VisibleFields
+		value, err := r.FieldByIndexErr(field.Index)
+		if err != nil {
+			return reflect.Value{}, nil
+		}
+		return value, nil
+	}
+
+	method, ok := o.accessors[accessorField{owner: r.Type(), field: field.Name}]
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("unexported field %s of %s has no accessor", field.Name, r.Type().Name())
+	}
+
+	results := r.MethodByName(method).Call(nil)
+	if len(results) == 2 && !results[1].IsNil() {
+		return reflect.Value{}, results[1].Interface().(error)
+	}
+	return results[0], nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// The content type of blobs tagged without one.
+const defaultBlobContentType = "application/octet-stream"
+
+// Returns the content type of a field tagged as blob.
+func (t fieldTag) blobContentType() string {
+	if contentType := t.options["blob"]; contentType != "" {
+		return contentType
+	}
+	return defaultBlobContentType
+}
+
+// Executes a query that selects a single field tagged with
+// `graphql:"blob=<content type>"` and writes its bytes to the response with
+// the content type of the tag, so binary data exposed as base64 in GraphQL
+// can be downloaded directly:
+//
+//	func DownloadPhoto(c echo.Context) error {
+//		query := fmt.Sprintf(`{ dogs(where: {name: %q}) { photo } }`, c.Param("name"))
+//		return ServeBlob(c.Response(), "dogs", dogs, query)
+//	}
+//
+// Every selection set of the query must contain exactly one field, and lists
+// on the way must contain exactly one element. A null blob responds with 404.
+// If an error is returned, nothing has been written to the response.
+func ServeBlob[T any](w http.ResponseWriter, rootField string, o T, query string, opts ...Option) error {
+	options := newOptions(opts)
+	schema, err := buildSchema(rootField, o, options)
+	if err != nil {
+		return err
+	}
+
+	operation, _, err := parseOperation(query)
+	if err != nil {
+		return err
+	}
+	if operation == nil {
+		return errors.New("query contains no operation")
+	}
+
+	// Follow the single selected field at each level down to the blob
+	var parent graphql.Type = schema.QueryType()
+	var keys []string
+	var blob schemaField
+	set := operation.SelectionSet
+	for set != nil {
+		if len(set.Selections) != 1 {
+			return errors.New("blob query must select exactly one field at each level")
+		}
+		selection, ok := set.Selections[0].(*ast.Field)
+		if !ok {
+			return errors.New("blob query can't contain fragments")
+		}
+
+		object, ok := parent.(*graphql.Object)
+		if !ok {
+			return fmt.Errorf("field %s must be selected on an object", selection.Name.Value)
+		}
+		field, ok := object.Fields()[selection.Name.Value]
+		if !ok {
+			return fmt.Errorf("unknown field %s of %s", selection.Name.Value, object.Name())
+		}
+
+		key := selection.Name.Value
+		if selection.Alias != nil {
+			key = selection.Alias.Value
+		}
+		keys = append(keys, key)
+
+		blob = schemaField{typeName: object.Name(), fieldName: selection.Name.Value}
+		parent = graphql.GetNamed(field.Type).(graphql.Type)
+		set = selection.SelectionSet
+	}
+
+	contentType, ok := options.blobs[blob]
+	if !ok {
+		return fmt.Errorf("field %s of %s is not tagged as blob", blob.fieldName, blob.typeName)
+	}
+
+	result, err := querySchema(schema, query, options)
+	if err != nil {
+		return err
+	}
+
+	value := result.Data
+	for _, key := range keys {
+		var ok bool
+		value, ok = objectField(value, key)
+		if !ok {
+			return errors.New("blob query must resolve to a single value")
+		}
+
+		if list, ok := value.([]any); ok {
+			if len(list) != 1 {
+				return fmt.Errorf("blob query must select a single element of %s, got %d", key, len(list))
+			}
+			value = list[0]
+		}
+	}
+
+	if value == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return nil
+	}
+
+	b, err := base64.StdEncoding.DecodeString(value.(string))
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(b)))
+	_, err = w.Write(b)
+	return err
+}
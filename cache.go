@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/printer"
+)
+
+// Stores the serialized results of a ResultCache. Implementations
+// must be safe for concurrent use.
+type CacheStore interface {
+	// Returns the stored value, or false if there is none or it expired.
+	Get(key string) ([]byte, bool)
+	// Stores the value for the given duration.
+	Set(key string, value []byte, ttl time.Duration)
+}
+
+// Caches the serialized results of QueryStructViaGraphql, so repeated queries
+// are answered without building the schema and executing them again.
+// Results are keyed by the root field and the normalized query, which ignores
+// differences in whitespace and comments. Queries selecting function fields,
+// methods or the '_service' field are never cached, since their values can
+// change with every call. Errors are not cached either.
+//
+// The cache doesn't notice changes of the queried data, which is why it is meant
+// for data that changes infrequently, with a TTL that bounds the staleness.
+// Use a separate cache for every data set and set of options.
+type ResultCache struct {
+	Store CacheStore
+	TTL   time.Duration
+}
+
+// Creates a result cache that keeps the results in memory for the given duration.
+func NewResultCache(ttl time.Duration) *ResultCache {
+	return &ResultCache{Store: &memoryCacheStore{entries: map[string]memoryCacheEntry{}}, TTL: ttl}
+}
+
+// Answers queries from the given cache, see ResultCache.
+func WithResultCache(cache *ResultCache) Option {
+	return func(o *options) {
+		o.resultCache = cache
+	}
+}
+
+// Returns the key of the query and its variables, or false if the query can't
+// be parsed. Such queries are not cached, executing them reports the syntax error.
+func (c *ResultCache) key(rootField, query string, variables map[string]any) (string, bool) {
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return "", false
+	}
+
+	// encoding/json sorts the keys of maps, so equal variables have equal keys
+	b, err := json.Marshal(variables)
+	if err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("%s\x00%s\x00%s", rootField, printer.Print(doc), b), true
+}
+
+// Returns true if the query doesn't select any function field or method,
+// whose values can change between calls, see options.reflectedFields.
+func isStaticQuery(schema graphql.Schema, query string, options *options) (bool, error) {
+	operation, fragments, err := parseOperation(query)
+	if err != nil || operation == nil {
+		return false, err
+	}
+
+	static := true
+	walkSelectedFields(schema, schema.QueryType(), operation.SelectionSet, fragments, func(parent graphql.Type, name string, _ *graphql.FieldDefinition) {
+		if kind := options.reflectedFields[schemaField{typeName: parent.Name(), fieldName: name}].kind; kind == funcField || kind == methodField {
+			static = false
+		}
+	})
+	return static, nil
+}
+
+type memoryCacheEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// The in-memory store of NewResultCache.
+type memoryCacheStore struct {
+	mutex   sync.Mutex
+	entries map[string]memoryCacheEntry
+
+	// Expired entries are removed once the store has doubled in size since the last sweep
+	sweepAt int
+}
+
+func (s *memoryCacheStore) Get(key string) ([]byte, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(s.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (s *memoryCacheStore) Set(key string, value []byte, ttl time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	s.entries[key] = memoryCacheEntry{value: value, expires: now.Add(ttl)}
+
+	if len(s.entries) >= s.sweepAt {
+		for k, entry := range s.entries {
+			if now.After(entry.expires) {
+				delete(s.entries, k)
+			}
+		}
+		s.sweepAt = max(2*len(s.entries), 64)
+	}
+}
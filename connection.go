@@ -0,0 +1,243 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+// The prefix of the decoded cursors of connections, see connectionCursor.
+const cursorPrefix = "cursor:"
+
+// The value of a connection field, see WithConnections.
+type listConnection struct {
+	Edges    []connectionEdge
+	PageInfo connectionPageInfo
+}
+
+type connectionEdge struct {
+	Node   any
+	Cursor string
+}
+
+type connectionPageInfo struct {
+	HasNextPage     bool
+	HasPreviousPage bool
+	StartCursor     *string
+	EndCursor       *string
+}
+
+// Returns the PageInfo object shared by all connections of a schema, as the
+// Relay specification requires. It is created per schema, since resolve hooks
+// wrap the resolvers of the objects of a schema, see hookResolvers.
+func createPageInfoObject(typesMap map[string]Pair[graphql.Output, graphql.Fields]) graphql.Output {
+	const name = "PageInfo"
+	if knownType, ok := typesMap[name]; ok {
+		return knownType.First
+	}
+
+	fields := graphql.Fields{
+		"hasNextPage": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Boolean),
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source.(connectionPageInfo).HasNextPage, nil
+			},
+		},
+		"hasPreviousPage": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Boolean),
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source.(connectionPageInfo).HasPreviousPage, nil
+			},
+		},
+		"startCursor": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source.(connectionPageInfo).StartCursor, nil
+			},
+		},
+		"endCursor": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source.(connectionPageInfo).EndCursor, nil
+			},
+		},
+	}
+
+	o := graphql.NewObject(graphql.ObjectConfig{
+		Name:   name,
+		Fields: fields,
+	})
+	typesMap[name] = Pair[graphql.Output, graphql.Fields]{First: o, Second: fields}
+	return o
+}
+
+// Exposes every list of structs as a Relay connection for cursor based
+// pagination, e.g. for infinite scrolling:
+//
+//	dogs(first: 10, after: "Y3Vyc29yOjk=") {
+//		edges { node { name } cursor }
+//		pageInfo { hasNextPage endCursor }
+//	}
+//
+// Single fields can be exposed as connections with the `graphql:"connection"`
+// tag instead. The cursors are base64 encoded positions in the list after
+// applying the 'where' and 'orderBy' arguments, so they stay valid as long as
+// the arguments and the list don't change. Connections take precedence over
+// page objects, see WithPaginatedLists.
+func WithConnections() Option {
+	return func(o *options) {
+		o.connections = true
+	}
+}
+
+// Returns true if a field of type t is exposed as a connection.
+func (o *options) connection(t reflect.Type, tag fieldTag) bool {
+	if !o.connections && !tag.has("connection") {
+		return false
+	}
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && indirectType(t.Elem()).Kind() == reflect.Struct
+}
+
+// Creates the connection object and its edge object for lists with the given element type.
+func createConnectionObject(t reflect.Type, list graphql.Output, typesMap map[string]Pair[graphql.Output, graphql.Fields], options *options) graphql.Output {
+	name := options.typeNamePart(t.Elem()) + "Connection"
+	if knownType, ok := typesMap[name]; ok {
+		return knownType.First
+	}
+
+	edgeName := options.typeNamePart(t.Elem()) + "Edge"
+	edgeFields := graphql.Fields{
+		"node": &graphql.Field{
+			Name: "Node",
+			Type: list.(*graphql.List).OfType,
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source.(connectionEdge).Node, nil
+			},
+		},
+		"cursor": &graphql.Field{
+			Name: "Cursor",
+			Type: graphql.NewNonNull(graphql.String),
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source.(connectionEdge).Cursor, nil
+			},
+		},
+	}
+	edge := graphql.NewObject(graphql.ObjectConfig{
+		Name:   edgeName,
+		Fields: edgeFields,
+	})
+	typesMap[edgeName] = Pair[graphql.Output, graphql.Fields]{First: edge, Second: edgeFields}
+
+	fields := graphql.Fields{
+		"edges": &graphql.Field{
+			Name: "Edges",
+			Type: graphql.NewList(edge),
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source.(listConnection).Edges, nil
+			},
+		},
+		"pageInfo": &graphql.Field{
+			Name: "PageInfo",
+			Type: graphql.NewNonNull(createPageInfoObject(typesMap)),
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source.(listConnection).PageInfo, nil
+			},
+		},
+	}
+
+	o := graphql.NewObject(graphql.ObjectConfig{
+		Name:   name,
+		Fields: fields,
+	})
+	typesMap[name] = Pair[graphql.Output, graphql.Fields]{First: o, Second: fields}
+	return o
+}
+
+// Creates the arguments of a connection, which are the 'where', 'orderBy' and
+// 'distinct' arguments of the elements and the forward pagination arguments.
+func createConnectionArguments(fieldName string, t reflect.Type, filterMap map[string]graphql.ArgumentConfig, options *options) (graphql.FieldConfigArgument, error) {
+	where, err := createFilterArgument(fieldName, indirectType(t.Elem()), filterMap, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return graphql.FieldConfigArgument{
+		"where":     where,
+		orderByArg:  &graphql.ArgumentConfig{Type: orderByInput},
+		distinctArg: &graphql.ArgumentConfig{Type: graphql.String},
+		"first":     &graphql.ArgumentConfig{Type: graphql.Int},
+		"after":     &graphql.ArgumentConfig{Type: graphql.String},
+	}, nil
+}
+
+// Filters and sorts the list like paginateList and returns the edges
+// of the first elements after the cursor of the 'after' argument.
+func connectList(r reflect.Value, p graphql.ResolveParams, options *options) (any, error) {
+	for r.Kind() == reflect.Pointer || r.Kind() == reflect.Interface {
+		if r.IsNil() {
+			return nil, nil
+		}
+		r = r.Elem()
+	}
+
+	matches, err := matchingElements(r, p, options)
+	if err != nil {
+		return nil, err
+	}
+
+	total := matches.Len()
+	start, end := 0, total
+	if after, ok := p.Args["after"].(string); ok {
+		i, err := parseConnectionCursor(after)
+		if err != nil {
+			return nil, err
+		}
+		start = min(i+1, total)
+	}
+	if first, ok := p.Args["first"].(int); ok {
+		if first < 0 {
+			return nil, fmt.Errorf("first must not be negative, got %d", first)
+		}
+		end = start + min(first, total-start)
+	}
+
+	connection := listConnection{
+		Edges: make([]connectionEdge, 0, end-start),
+		PageInfo: connectionPageInfo{
+			HasNextPage:     end < total,
+			HasPreviousPage: start > 0,
+		},
+	}
+	for i := start; i < end; i++ {
+		connection.Edges = append(connection.Edges, connectionEdge{
+			Node:   matches.Index(i).Interface(),
+			Cursor: connectionCursor(i),
+		})
+	}
+	if len(connection.Edges) > 0 {
+		connection.PageInfo.StartCursor = &connection.Edges[0].Cursor
+		connection.PageInfo.EndCursor = &connection.Edges[len(connection.Edges)-1].Cursor
+	}
+	return connection, nil
+}
+
+// Returns the opaque cursor of the element at position i.
+func connectionCursor(i int) string {
+	return base64.StdEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(i)))
+}
+
+// Returns the position encoded in a cursor, see connectionCursor.
+func parseConnectionCursor(cursor string) (int, error) {
+	decoded, err := base64.StdEncoding.DecodeString(cursor)
+	if err == nil {
+		position, ok := strings.CutPrefix(string(decoded), cursorPrefix)
+		if i, err := strconv.Atoi(position); ok && err == nil && i >= 0 {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid cursor %q", cursor)
+}
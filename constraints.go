@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Constraints on the values a field can be filtered by, read from the tag:
+// Age int `graphql:"filterMin=0,filterMax=150"`
+// Name string `graphql:"filterMaxLen=64"`
+//
+// The filter fields keep the input type of the field, so that variables of
+// that type can be given for them, and the values are checked before the
+// elements are filtered, see checkFilterValues. Only the values of the
+// field itself and of its 'eq' operator are constrained, see
+// WithFilterOperatorObjects, operators like 'age_lt' are not: filtering by
+// 'age_lt: 151' is meaningful even though no age is greater than 150.
+type filterConstraints struct {
+	min, max  *float64
+	maxLen    int
+	hasMaxLen bool
+}
+
+// Returns the constraints of the tag, or nil if the tag has none.
+func parseFilterConstraints(field reflect.StructField, tag fieldTag) (*filterConstraints, error) {
+	if !tag.has("filterMin") && !tag.has("filterMax") && !tag.has("filterMaxLen") {
+		return nil, nil
+	}
+
+	c := &filterConstraints{}
+	for _, option := range []string{"filterMin", "filterMax"} {
+		value, ok := tag.options[option]
+		if !ok {
+			continue
+		}
+
+		bound, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: invalid %s %q", field.Name, option, value)
+		}
+		if option == "filterMin" {
+			c.min = &bound
+		} else {
+			c.max = &bound
+		}
+	}
+	if c.min != nil && c.max != nil && *c.min > *c.max {
+		return nil, fmt.Errorf("field %s: filterMin %v is greater than filterMax %v", field.Name, *c.min, *c.max)
+	}
+
+	if value, ok := tag.options["filterMaxLen"]; ok {
+		maxLen, err := strconv.Atoi(value)
+		if err != nil || maxLen < 0 {
+			return nil, fmt.Errorf("field %s: invalid filterMaxLen %q", field.Name, value)
+		}
+		c.maxLen = maxLen
+		c.hasMaxLen = true
+	}
+	return c, nil
+}
+
+// Returns an error if the constraints don't fit the filter input type t
+// of the field, e.g. filterMaxLen on a number field.
+func (c *filterConstraints) validate(field reflect.StructField, t graphql.Output) error {
+	numeric := t == graphql.Float || t == graphql.Int || t == int64Scalar || t == uint64Scalar
+	if (c.min != nil || c.max != nil) && !numeric {
+		return fmt.Errorf("field %s: filterMin and filterMax require a numeric field", field.Name)
+	}
+	if c.hasMaxLen && t != graphql.String && t != graphql.ID {
+		return fmt.Errorf("field %s: filterMaxLen requires a string field", field.Name)
+	}
+	return nil
+}
+
+// Returns an error naming the filter field at path if the value violates
+// the constraints, e.g. "where.size must be <= 10, got 11".
+func (c *filterConstraints) check(path string, value any) error {
+	var number float64
+	switch v := value.(type) {
+	case float64:
+		number = v
+	case int:
+		number = float64(v)
+	case int64:
+		number = float64(v)
+	case uint64:
+		number = float64(v)
+	case string:
+		if n := utf8.RuneCountInString(v); c.hasMaxLen && n > c.maxLen {
+			return fmt.Errorf("%s must be at most %d characters long, got %d", path, c.maxLen, n)
+		}
+		return nil
+	default:
+		return nil
+	}
+
+	if c.min != nil && number < *c.min {
+		return fmt.Errorf("%s must be >= %v, got %v", path, *c.min, value)
+	}
+	if c.max != nil && number > *c.max {
+		return fmt.Errorf("%s must be <= %v, got %v", path, *c.max, value)
+	}
+	return nil
+}
+
+// Describes the constraints for the filter field in the schema.
+func (c *filterConstraints) description() string {
+	var parts []string
+	if c.min != nil {
+		parts = append(parts, fmt.Sprintf("at least %v", *c.min))
+	}
+	if c.max != nil {
+		parts = append(parts, fmt.Sprintf("at most %v", *c.max))
+	}
+	if c.hasMaxLen {
+		parts = append(parts, fmt.Sprintf("at most %d characters long", c.maxLen))
+	}
+	return "Must be " + strings.Join(parts, " and ") + "."
+}
+
+// Returns an error for the first value of the filter on elements of type t
+// that violates the constraints of its field, see filterConstraints, or is
+// an invalid regular expression, see regexError. path names the filter in
+// the error, e.g. 'where'. Nested filters of struct fields, the alternatives
+// in '_or' and the 'eq' values of operator objects are checked as well.
+func checkFilterValues(path string, t reflect.Type, filter map[string]any, options *options) error {
+	t = indirectType(t)
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for fieldName, filterValue := range filter {
+		if fieldName == orFilterField {
+			alternatives, _ := filterValue.([]any)
+			for i, alternative := range alternatives {
+				alternative, ok := alternative.(map[string]any)
+				if !ok {
+					continue
+				}
+				if err := checkFilterValues(fmt.Sprintf("%s.%s[%d]", path, fieldName, i), t, alternative, options); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if err, ok := filterValue.(*regexError); ok {
+			return fmt.Errorf("%s.%s: %w", path, fieldName, err)
+		}
+
+		// Operator fields aren't fields of the struct and stay unconstrained
+		field, ok := fieldByGraphqlName(t, fieldName)
+		if !ok {
+			continue
+		}
+		fieldPath := path + "." + fieldName
+
+		nested, isObject := filterValue.(map[string]any)
+		if isObject && isNestedFilterType(indirectType(field.Type), options) {
+			if err := checkFilterValues(fieldPath, field.Type, nested, options); err != nil {
+				return err
+			}
+			continue
+		}
+		if isObject {
+			// Operator objects, see WithFilterOperatorObjects
+			for operator, value := range nested {
+				if err, ok := value.(*regexError); ok {
+					return fmt.Errorf("%s.%s: %w", fieldPath, operator, err)
+				}
+			}
+			if filterValue, ok = nested[equalOperator]; !ok {
+				continue
+			}
+			fieldPath += "." + equalOperator
+		}
+
+		// The tag has been validated when the schema was built
+		c, _ := parseFilterConstraints(field, parseFieldTag(field))
+		if c == nil {
+			continue
+		}
+		if err := c.check(fieldPath, filterValue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
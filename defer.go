@@ -0,0 +1,392 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/kinds"
+	"github.com/graphql-go/graphql/language/printer"
+	"github.com/graphql-go/graphql/language/visitor"
+)
+
+// The content type of the responses written by QueryStructViaGraphqlDeferred.
+const DeferContentType = `multipart/mixed; boundary="-"`
+
+// Marks a field whose value is delivered after the rest of the result:
+// dogs { name relatives @defer { name } }
+var deferDirective = graphql.NewDirective(graphql.DirectiveConfig{
+	Name:        "defer",
+	Description: "Delivers the field in a subsequent payload after the initial result.",
+	Locations:   []string{graphql.DirectiveLocationField},
+	Args: graphql.FieldConfigArgument{
+		"label": &graphql.ArgumentConfig{
+			Type:        graphql.String,
+			Description: "Identifies the payloads of the deferred field.",
+		},
+	},
+})
+
+// A field selected with @defer and the selections leading to it from the
+// operation, where the last selection is the deferred field itself.
+type deferredField struct {
+	chain []ast.Selection
+	label string
+}
+
+// One part of an incrementally delivered result.
+type deferPayload struct {
+	Data       any            `json:"data,omitempty"`
+	Path       []any          `json:"path,omitempty"`
+	Label      string         `json:"label,omitempty"`
+	Errors     []deferError   `json:"errors,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+	HasNext    bool           `json:"hasNext"`
+}
+
+type deferError struct {
+	Message string `json:"message"`
+}
+
+// Executes the query like QueryStructViaGraphql, but delivers fields selected
+// with @defer incrementally. The initial payload contains all other fields,
+// every deferred field is resolved afterwards and sent as a patch for each
+// object that selects it. This is meant for slow func fields and methods, so
+// clients can render the fast fields first:
+//
+//	dogs { name relatives @defer(label: "family") { name } }
+//
+// Every deferred field is resolved by a query of its own that selects the
+// fields leading to it again, so func fields and methods on the path to a
+// deferred field run once for the initial payload and once more for each
+// deferred field below them. Expensive fields should be deferred themselves
+// rather than lead to several deferred fields.
+//
+// The payloads are written as multipart/mixed parts (see DeferContentType) and
+// flushed if the writer is an http.Flusher. If a deferred field fails, a final
+// part with the error is written and the error is returned.
+func QueryStructViaGraphqlDeferred[T any](w io.Writer, rootField string, o T, query string, opts ...Option) error {
+	options := newOptions(opts)
+	schema, err := buildSchema(rootField, o, options)
+	if err != nil {
+		return err
+	}
+
+	operation, fragments, err := parseOperation(query)
+	if err != nil {
+		return err
+	}
+	if operation == nil {
+		return errors.New("query contains no operation")
+	}
+
+	// Removes the deferred fields from the operation, which leaves the initial query
+	deferred := splitDeferredFields(operation.SelectionSet, nil)
+
+	result, err := querySchema(schema, printOperation(operation, fragments), options)
+	if err != nil {
+		return err
+	}
+
+	mw := newMultipartWriter(w)
+	err = mw.write(deferPayload{Data: result.Data, Extensions: result.Extensions, HasNext: len(deferred) > 0})
+	if err != nil {
+		return err
+	}
+
+	for i, field := range deferred {
+		last := i == len(deferred)-1
+
+		patches, err := queryDeferredField(schema, operation, fragments, field, options)
+		if err != nil {
+			if writeErr := mw.write(deferPayload{Errors: []deferError{{Message: err.Error()}}}); writeErr != nil {
+				return writeErr
+			}
+			if closeErr := mw.close(); closeErr != nil {
+				return closeErr
+			}
+			return err
+		}
+
+		for j, patch := range patches {
+			patch.HasNext = !last || j < len(patches)-1
+			if err := mw.write(patch); err != nil {
+				return err
+			}
+		}
+
+		// Tell the client that the result is complete if there was nothing left to patch
+		if last && len(patches) == 0 {
+			if err := mw.write(deferPayload{}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return mw.close()
+}
+
+// Removes the fields selected with @defer from the selection set and returns them.
+// Nested deferred fields are delivered as part of the outermost one. A selection
+// set that would become empty selects '__typename' instead to remain valid.
+func splitDeferredFields(set *ast.SelectionSet, chain []ast.Selection) []deferredField {
+	if set == nil {
+		return nil
+	}
+
+	var deferred []deferredField
+	selections := make([]ast.Selection, 0, len(set.Selections))
+	for _, selection := range set.Selections {
+		// Copy the chain, since the slices of sibling selections share the same array
+		selectionChain := append(append([]ast.Selection{}, chain...), selection)
+
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if directive := findDeferDirective(selection.Directives); directive != nil {
+				field := *selection
+				field.Directives = withoutDirective(selection.Directives, directive)
+				selectionChain[len(selectionChain)-1] = &field
+
+				deferred = append(deferred, deferredField{chain: selectionChain, label: deferLabel(directive)})
+				continue
+			}
+			deferred = append(deferred, splitDeferredFields(selection.SelectionSet, selectionChain)...)
+		case *ast.InlineFragment:
+			deferred = append(deferred, splitDeferredFields(selection.SelectionSet, selectionChain)...)
+		}
+		selections = append(selections, selection)
+	}
+
+	if len(selections) == 0 {
+		selections = append(selections, ast.NewField(&ast.Field{Name: ast.NewName(&ast.Name{Value: "__typename"})}))
+	}
+	set.Selections = selections
+	return deferred
+}
+
+func findDeferDirective(directives []*ast.Directive) *ast.Directive {
+	for _, directive := range directives {
+		if directive.Name != nil && directive.Name.Value == deferDirective.Name {
+			return directive
+		}
+	}
+	return nil
+}
+
+func withoutDirective(directives []*ast.Directive, remove *ast.Directive) []*ast.Directive {
+	var rest []*ast.Directive
+	for _, directive := range directives {
+		if directive != remove {
+			rest = append(rest, directive)
+		}
+	}
+	return rest
+}
+
+// Returns the value of the 'label' argument if it is given as a string literal.
+func deferLabel(directive *ast.Directive) string {
+	for _, arg := range directive.Arguments {
+		if arg.Name != nil && arg.Name.Value == "label" {
+			if value, ok := arg.Value.(*ast.StringValue); ok {
+				return value.Value
+			}
+		}
+	}
+	return ""
+}
+
+// Executes a query that selects only the deferred field and the fields leading
+// to it, and splits the result into one patch per object containing the field.
+func queryDeferredField(schema graphql.Schema, operation *ast.OperationDefinition, fragments map[string]*ast.FragmentDefinition, field deferredField, options *options) ([]deferPayload, error) {
+	// Build the selection sets from the deferred field up to the operation
+	var selection ast.Selection
+	var keys []string
+	for i := len(field.chain) - 1; i >= 0; i-- {
+		switch node := field.chain[i].(type) {
+		case *ast.Field:
+			f := *node
+			if selection != nil {
+				f.SelectionSet = ast.NewSelectionSet(&ast.SelectionSet{Selections: []ast.Selection{selection}})
+			}
+			selection = &f
+
+			key := node.Name.Value
+			if node.Alias != nil {
+				key = node.Alias.Value
+			}
+			keys = append([]string{key}, keys...)
+		case *ast.InlineFragment:
+			fragment := *node
+			fragment.SelectionSet = ast.NewSelectionSet(&ast.SelectionSet{Selections: []ast.Selection{selection}})
+			selection = &fragment
+		}
+	}
+
+	op := *operation
+	op.SelectionSet = ast.NewSelectionSet(&ast.SelectionSet{Selections: []ast.Selection{selection}})
+
+	result, err := querySchema(schema, printOperation(&op, fragments), options)
+	if err != nil {
+		return nil, err
+	}
+
+	var patches []deferPayload
+	collectPatches(result.Data, keys, nil, func(data any, path []any) {
+		patches = append(patches, deferPayload{Data: data, Path: path, Label: field.label, Extensions: result.Extensions})
+	})
+	return patches, nil
+}
+
+// Follows the response keys through the data, descending into every element of
+// lists on the way, and emits the last key of each object it reaches together
+// with the path of that object.
+func collectPatches(value any, keys []string, path []any, emit func(data any, path []any)) {
+	switch v := value.(type) {
+	case []any:
+		for i, element := range v {
+			collectPatches(element, keys, append(append([]any{}, path...), i), emit)
+		}
+	case map[string]any, orderedObject:
+		fieldValue, ok := objectField(v, keys[0])
+		if !ok {
+			return
+		}
+
+		if len(keys) == 1 {
+			emit(orderedObject{{Key: keys[0], Value: fieldValue}}, path)
+			return
+		}
+		collectPatches(fieldValue, keys[1:], append(append([]any{}, path...), keys[0]), emit)
+	}
+}
+
+// Returns the value of a field of an object in the result data.
+func objectField(object any, key string) (any, bool) {
+	switch o := object.(type) {
+	case map[string]any:
+		value, ok := o[key]
+		return value, ok
+	case orderedObject:
+		for _, entry := range o {
+			if entry.Key == key {
+				return entry.Value, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Prints the operation together with the fragments it uses,
+// since unused fragments fail the validation of the query.
+func printOperation(operation *ast.OperationDefinition, fragments map[string]*ast.FragmentDefinition) string {
+	definitions := []ast.Node{operation}
+
+	used := map[string]bool{}
+	collectFragmentSpreads(operation.SelectionSet, fragments, used)
+	for name := range used {
+		definitions = append(definitions, fragments[name])
+	}
+
+	// Variables only used by the removed fields have to be removed as well,
+	// since graphql-go rejects operations that declare unused variables
+	if len(operation.VariableDefinitions) > 0 {
+		variables := map[string]bool{}
+		for _, definition := range definitions {
+			collectVariables(definition, variables)
+		}
+
+		op := *operation
+		op.VariableDefinitions = nil
+		for _, definition := range operation.VariableDefinitions {
+			if variables[definition.Variable.Name.Value] {
+				op.VariableDefinitions = append(op.VariableDefinitions, definition)
+			}
+		}
+		definitions[0] = &op
+	}
+
+	return fmt.Sprint(printer.Print(ast.NewDocument(&ast.Document{Definitions: definitions})))
+}
+
+// Adds the names of the variables used in the selections and directives of the
+// definition to the set. The definitions of the variables are not visited.
+func collectVariables(definition ast.Node, variables map[string]bool) {
+	visitor.Visit(definition, &visitor.VisitorOptions{
+		KindFuncMap: map[string]visitor.NamedVisitFuncs{
+			kinds.VariableDefinition: {
+				Kind: func(p visitor.VisitFuncParams) (string, any) {
+					return visitor.ActionSkip, nil
+				},
+			},
+			kinds.Variable: {
+				Kind: func(p visitor.VisitFuncParams) (string, any) {
+					if variable, ok := p.Node.(*ast.Variable); ok {
+						variables[variable.Name.Value] = true
+					}
+					return visitor.ActionNoChange, nil
+				},
+			},
+		},
+	}, nil)
+}
+
+func collectFragmentSpreads(set *ast.SelectionSet, fragments map[string]*ast.FragmentDefinition, used map[string]bool) {
+	if set == nil {
+		return
+	}
+
+	for _, selection := range set.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			collectFragmentSpreads(selection.SelectionSet, fragments, used)
+		case *ast.InlineFragment:
+			collectFragmentSpreads(selection.SelectionSet, fragments, used)
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			if fragment, ok := fragments[name]; ok && !used[name] {
+				used[name] = true
+				collectFragmentSpreads(fragment.SelectionSet, fragments, used)
+			}
+		}
+	}
+}
+
+// Writes JSON payloads as the parts of a multipart/mixed response with the boundary '-'.
+type multipartWriter struct {
+	w       io.Writer
+	flusher http.Flusher
+}
+
+func newMultipartWriter(w io.Writer) *multipartWriter {
+	flusher, _ := w.(http.Flusher)
+	return &multipartWriter{w: w, flusher: flusher}
+}
+
+func (m *multipartWriter) write(payload deferPayload) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(m.w, "\r\n---\r\nContent-Type: application/json; charset=utf-8\r\n\r\n%s", b); err != nil {
+		return err
+	}
+
+	if m.flusher != nil {
+		m.flusher.Flush()
+	}
+	return nil
+}
+
+// Writes the closing boundary.
+func (m *multipartWriter) close() error {
+	_, err := io.WriteString(m.w, "\r\n-----\r\n")
+	if m.flusher != nil {
+		m.flusher.Flush()
+	}
+	return err
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/graphql-go/graphql"
+)
+
+// A deprecated field selected by a query, reported in the result extensions.
+type Deprecation struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// Walks through the selections of the query alongside the schema types
+// and returns all selected fields that are deprecated, sorted by name.
+func collectDeprecations(schema graphql.Schema, query string) ([]Deprecation, error) {
+	operation, fragments, err := parseOperation(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if operation == nil {
+		return nil, nil
+	}
+
+	found := map[string]Deprecation{}
+	walkSelectedFields(schema, schema.QueryType(), operation.SelectionSet, fragments, func(parent graphql.Type, _ string, field *graphql.FieldDefinition) {
+		if field.DeprecationReason != "" {
+			name := parent.Name() + "." + field.Name
+			found[name] = Deprecation{Field: name, Reason: field.DeprecationReason}
+		}
+	})
+
+	deprecations := make([]Deprecation, 0, len(found))
+	for _, d := range found {
+		deprecations = append(deprecations, d)
+	}
+	sort.Slice(deprecations, func(i, j int) bool {
+		return deprecations[i].Field < deprecations[j].Field
+	})
+	return deprecations, nil
+}
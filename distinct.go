@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+// The argument of lists of structs that removes duplicates, see distinctList.
+const distinctArg = "distinct"
+
+// Returns the elements of the list of structs r without the elements whose
+// field named by the 'distinct' argument repeats the value of an earlier
+// element, e.g. one dog of each color:
+//
+//	dogs(distinct: "color") { name color }
+//
+// The kept elements stay in their order. Nil elements and nil pointer
+// fields count as one value, so the first of them is kept.
+func distinctList(r reflect.Value, p graphql.ResolveParams, options *options) (reflect.Value, error) {
+	fieldName, ok := p.Args[distinctArg].(string)
+	if !ok {
+		return r, nil
+	}
+
+	elem := indirectType(r.Type().Elem())
+	field, ok := fieldByGraphqlName(elem, fieldName)
+	if !ok {
+		return r, fmt.Errorf("%s has no field %q to be distinct by", elem.Name(), fieldName)
+	}
+	if t := indirectType(field.Type); !isSortable(t) && t.Kind() != reflect.Bool {
+		return r, fmt.Errorf("field %q of %s can't be distinct by", fieldName, elem.Name())
+	}
+
+	seen := map[any]bool{}
+	distinct := reflect.MakeSlice(reflect.SliceOf(r.Type().Elem()), 0, r.Len())
+	for i := 0; i < r.Len(); i++ {
+		var key any
+		if element, ok := indirectValue(r.Index(i)); ok {
+			value, err := options.fieldValue(element, field)
+			if err != nil {
+				return r, err
+			}
+			if value, ok := indirectValue(value); ok {
+				key = distinctKey(value)
+			}
+		}
+
+		if !seen[key] {
+			seen[key] = true
+			distinct = reflect.Append(distinct, r.Index(i))
+		}
+	}
+	return distinct, nil
+}
+
+// Returns the key of a field value in the set of seen values. Times
+// are equal if they are the same instant, regardless of the location.
+func distinctKey(value reflect.Value) any {
+	if value.Type() == typeTime {
+		return value.Interface().(time.Time).UTC().Round(0)
+	}
+	return value.Interface()
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+
+	"github.com/graphql-go/graphql"
+	"golang.org/x/exp/constraints"
+)
+
+// Valid GraphQL names, e.g. of enum values.
+var graphqlName = regexp.MustCompile(`^[_a-zA-Z][_a-zA-Z0-9]*$`)
+
+// A type registered via WithEnumValues with all of its valid values.
+type enumType struct {
+	values []any
+
+	// Built on first use, see options.enum
+	output *graphql.Enum
+	names  map[any]string
+	order  map[any]int
+	err    error
+}
+
+// Exposes the named type t as a GraphQL enum with the given values, which
+// are converted to t. The enum value names are the values themselves, or
+// the result of their String method if t implements fmt.Stringer:
+//
+//	type Color string
+//
+//	WithEnumValues(reflect.TypeOf(Color("")), []any{"red", "green", "blue"})
+//
+// Enum values are accepted in 'where' filters as well. Resolving a value
+// that isn't one of the declared values fails with an error.
+func WithEnumValues(t reflect.Type, values []any) Option {
+	return func(o *options) {
+		if o.enums == nil {
+			o.enums = map[reflect.Type]*enumType{}
+		}
+		o.enums[t] = &enumType{values: values}
+	}
+}
+
+// Exposes the named type T as a GraphQL enum with the given values like
+// WithEnumValues, but checks the values at compile time, e.g. for the
+// constants of the type:
+//
+//	type Color string
+//
+//	const (
+//		Black Color = "black"
+//		White Color = "white"
+//	)
+//
+//	WithEnum(Black, White)
+func WithEnum[T ~string | constraints.Integer | constraints.Float](values ...T) Option {
+	untyped := make([]any, len(values))
+	for i, value := range values {
+		untyped[i] = value
+	}
+	return WithEnumValues(reflect.TypeOf((*T)(nil)).Elem(), untyped)
+}
+
+// Returns the GraphQL enum of a type registered via WithEnumValues or
+// of a protobuf enum, or nil if the type isn't registered.
+func (o *options) enum(t reflect.Type) (*enumType, error) {
+	e, ok := o.enums[t]
+	if !ok && o.protobuf && isProtoEnum(t) {
+		// Protobuf enums are registered on first use, see WithProtobuf
+		WithEnumValues(t, protoEnumValues(t))(o)
+		e, ok = o.enums[t]
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	if e.output == nil && e.err == nil {
+		e.output, e.names, e.order, e.err = createEnum(t, e.values)
+	}
+	return e, e.err
+}
+
+// Creates the GraphQL enum of t and returns the names and the
+// declared positions of its values.
+func createEnum(t reflect.Type, values []any) (*graphql.Enum, map[any]string, map[any]int, error) {
+	if t.Name() == "" || getBasicOutput(t) == nil {
+		return nil, nil, nil, fmt.Errorf("enum type %s must be a named string, integer or float type", t)
+	}
+
+	config := graphql.EnumValueConfigMap{}
+	names := map[any]string{}
+	order := map[any]int{}
+	for i, value := range values {
+		r := reflect.ValueOf(value)
+		if !r.IsValid() || !r.CanConvert(t) {
+			return nil, nil, nil, fmt.Errorf("enum value %v can't be converted to %s", value, t.Name())
+		}
+		typed := r.Convert(t).Interface()
+
+		name := fmt.Sprint(typed)
+		if stringer, ok := typed.(fmt.Stringer); ok {
+			name = stringer.String()
+		}
+		if !graphqlName.MatchString(name) {
+			return nil, nil, nil, fmt.Errorf("enum value %q of %s is not a valid GraphQL name", name, t.Name())
+		}
+		if _, ok := config[name]; ok {
+			return nil, nil, nil, fmt.Errorf("enum value %q of %s is declared twice", name, t.Name())
+		}
+
+		// Resolvers return the typed value, which graphql-go serializes by looking it up
+		config[name] = &graphql.EnumValueConfig{Value: typed}
+		names[typed] = name
+		order[typed] = i
+	}
+
+	return graphql.NewEnum(graphql.EnumConfig{
+		Name:   t.Name(),
+		Values: config,
+	}), names, order, nil
+}
+
+// Returns the value for the enum, or an error if it isn't a declared value.
+func (e *enumType) resolve(r reflect.Value) (any, error) {
+	value := r.Interface()
+	if _, ok := e.names[value]; !ok {
+		return nil, fmt.Errorf("%v is not a value of enum %s", value, e.output.Name())
+	}
+	return value, nil
+}
+
+// Returns the position of the value in the declared order of the enum.
+// Undeclared values are placed after all declared values.
+func (e *enumType) position(r reflect.Value) int {
+	if i, ok := e.order[r.Interface()]; ok {
+		return i
+	}
+	return len(e.order)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// Where the value of a schema field comes from.
+type fieldKind int
+
+const (
+	// Fields without reflection metadata, e.g. '<field>Count' or the fields of page objects
+	generatedField fieldKind = iota
+	// Struct fields and the root field, whose values are read as they are
+	staticField
+	// Function fields, which are called on every query
+	funcField
+	// Methods, which are called on every query
+	methodField
+)
+
+func (k fieldKind) String() string {
+	switch k {
+	case staticField:
+		return "static"
+	case funcField:
+		return "func"
+	case methodField:
+		return "method"
+	}
+	return "generated"
+}
+
+// The reflection metadata of a schema field, collected while building the schema.
+type reflectedField struct {
+	goType reflect.Type
+	kind   fieldKind
+}
+
+// Records the Go type and kind of a field of the schema.
+func (o *options) addReflectedField(typeName, fieldName string, goType reflect.Type, kind fieldKind) {
+	if o.reflectedFields == nil {
+		o.reflectedFields = map[schemaField]reflectedField{}
+	}
+	o.reflectedFields[schemaField{typeName: typeName, fieldName: fieldName}] = reflectedField{goType: goType, kind: kind}
+}
+
+// Makes QueryStructViaGraphql return how the query maps onto the reflected types
+// instead of resolving it, e.g. to debug the schema without calling expensive
+// resolvers. The result mirrors the shape of the query:
+//
+//	{ "explain": { "dogs": {
+//		"goType": "[]main.Dog", "graphqlType": "[Dog]", "kind": "static",
+//		"args": { "where": "dogs" },
+//		"fields": { "name": { "goType": "string", "graphqlType": "String", "kind": "static" } }
+//	} } }
+//
+// The kind is "static" for struct fields, "func" for function fields, "method"
+// for methods and "generated" for fields without a Go counterpart, e.g.
+// '<field>Count'. The query is validated, but no resolver is called.
+func WithExplain() Option {
+	return func(o *options) {
+		o.explain = true
+	}
+}
+
+// The explanation of a selected field, see WithExplain.
+type fieldExplanation struct {
+	GoType      string            `json:"goType,omitempty"`
+	GraphqlType string            `json:"graphqlType"`
+	Kind        string            `json:"kind"`
+	Args        map[string]string `json:"args,omitempty"`
+	Fields      orderedObject     `json:"fields,omitempty"`
+}
+
+// Validates the query against the schema and returns the explanations
+// of its selected fields in the order they were selected.
+func explainQuery(schema graphql.Schema, query string, options *options) ([]byte, error) {
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return nil, err
+	}
+	if validation := graphql.ValidateDocument(&schema, doc, nil); !validation.IsValid {
+		return nil, errors.New(validation.Errors[0].Message)
+	}
+
+	operation, fragments, err := parseOperation(query)
+	if err != nil {
+		return nil, err
+	}
+	if operation == nil {
+		return nil, errors.New("query has no operation")
+	}
+
+	explanations := explainSelections(schema, schema.QueryType(), operation.SelectionSet, fragments, options, orderedObject{})
+	return options.marshalResult(map[string]any{"explain": explanations})
+}
+
+// Appends the explanations of the fields in the selection set to the object,
+// keyed by their response keys. Fragments are expanded in place.
+func explainSelections(schema graphql.Schema, parent graphql.Type, set *ast.SelectionSet, fragments map[string]*ast.FragmentDefinition, options *options, object orderedObject) orderedObject {
+	if set == nil {
+		return object
+	}
+
+	var fields graphql.FieldDefinitionMap
+	if parent, ok := parent.(*graphql.Object); ok {
+		fields = parent.Fields()
+	}
+
+	for _, selection := range set.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			field, ok := fields[selection.Name.Value]
+			if !ok {
+				continue
+			}
+
+			key := selection.Name.Value
+			if selection.Alias != nil {
+				key = selection.Alias.Value
+			}
+			if _, ok := objectField(object, key); ok {
+				continue
+			}
+
+			reflected := options.reflectedFields[schemaField{typeName: parent.Name(), fieldName: selection.Name.Value}]
+			explanation := &fieldExplanation{
+				GraphqlType: field.Type.String(),
+				Kind:        reflected.kind.String(),
+			}
+			if reflected.goType != nil {
+				explanation.GoType = reflected.goType.String()
+			}
+			if len(field.Args) > 0 {
+				explanation.Args = map[string]string{}
+				for _, arg := range field.Args {
+					explanation.Args[arg.Name()] = arg.Type.String()
+				}
+			}
+			if selection.SelectionSet != nil {
+				explanation.Fields = explainSelections(schema, graphql.GetNamed(field.Type).(graphql.Type), selection.SelectionSet, fragments, options, orderedObject{})
+			}
+			object = append(object, orderedEntry{Key: key, Value: explanation})
+		case *ast.InlineFragment:
+			t := parent
+			if selection.TypeCondition != nil {
+				t = schema.Type(selection.TypeCondition.Name.Value)
+			}
+			object = explainSelections(schema, t, selection.SelectionSet, fragments, options, object)
+		case *ast.FragmentSpread:
+			if fragment, ok := fragments[selection.Name.Value]; ok {
+				object = explainSelections(schema, schema.Type(fragment.TypeCondition.Name.Value), fragment.SelectionSet, fragments, options, object)
+			}
+		}
+	}
+	return object
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+// The field of filter objects that holds alternative filters, see matchesFilter.
+const orFilterField = "_or"
+
+// Creates the 'where' argument for lists of the given element type.
+//
+// Example syntax:
+// items (where: {X: "abc", Y: 2}) { X }
+// An element matches if all fields of the filter match. Alternatives
+// are given in the '_or' field, of which at least one has to match:
+// items (where: {_or: [{X: "abc"}, {Y: 2}]}) { X }
+// Fields can be compared with operators given as suffix as well:
+// items (where: {Y_gte: 2, X_ne: "abc"}) { X }
+//
+// Filter values can be constrained with tag options, see filterConstraints.
+func createFilterArgument(fieldName string, elem reflect.Type, filterMap map[string]graphql.ArgumentConfig, options *options) (*graphql.ArgumentConfig, error) {
+	argConfig, ok := filterMap[fieldName]
+	if !ok {
+		if _, err := createFilterInput(strings.ToLower(fieldName), fieldName, elem, filterMap, options); err != nil {
+			return nil, err
+		}
+		argConfig = filterMap[fieldName]
+	}
+	return &argConfig, nil
+}
+
+// Creates the filter object of the struct type elem with the given name and
+// registers it in the filterMap under the key. Returns the number of fields
+// of the filter object besides '_or'.
+func createFilterInput(typeName, key string, elem reflect.Type, filterMap map[string]graphql.ArgumentConfig, options *options) (int, error) {
+	fields := graphql.InputObjectConfigFieldMap{}
+
+	// The alternatives refer to the filter object itself, so its fields are a thunk
+	var filter *graphql.InputObject
+	filter = graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: typeName,
+		Fields: graphql.InputObjectConfigFieldMapThunk(func() graphql.InputObjectConfigFieldMap {
+			if _, ok := fields[orFilterField]; !ok {
+				fields[orFilterField] = &graphql.InputObjectFieldConfig{
+					Type: graphql.NewList(graphql.NewNonNull(filter)),
+				}
+			}
+			return fields
+		}),
+	})
+
+	// Registered before the fields are built, so that the nested
+	// filters of recursive types refer to the filter object as well
+	filterMap[key] = graphql.ArgumentConfig{
+		Type: filter,
+	}
+
+	for _, v := range reflect.VisibleFields(elem) {
+		// Unexported fields can only be filtered through their accessor
+		if _, hasAccessor, _ := options.accessor(elem, v); !v.IsExported() && !hasAccessor || options.skipProtoField(v) {
+			continue
+		}
+
+		// Output-only fields can be selected, but not filtered by
+		tag := parseFieldTag(v)
+		if tag.has("outputOnly") {
+			continue
+		}
+
+		name, visible := graphqlFieldName(v)
+		if !visible || !isJSONField(elem, v) || !graphqlName.MatchString(name) {
+			continue
+		}
+
+		// Pointer fields are filtered by the value they point to
+		fieldType := indirectType(v.Type)
+
+		t := options.basicOutput(fieldType)
+		if fieldType == typeDuration {
+			// Filtered by strings like "1h30m", see matchesValue
+			t = graphql.String
+		}
+		if output := taggedOutput(v, tag); output != nil {
+			t = output
+		}
+		enum, err := options.enum(fieldType)
+		if err != nil {
+			return 0, err
+		}
+		if enum != nil {
+			t = enum.output
+		}
+		if scalar, ok := options.scalars[fieldType]; ok {
+			t = scalar
+		}
+		if t == nil {
+			// Fields that can be nil can be filtered by that, e.g. 'owner_isNull'
+			addNullFilterField(fields, name, v.Type)
+
+			// Struct fields are filtered by the fields of the struct, see nestedFilterInput
+			nested, err := nestedFilterInput(fieldType, filterMap, options)
+			if err != nil {
+				return 0, err
+			}
+			if nested != nil {
+				fields[name] = &graphql.InputObjectFieldConfig{
+					Type:        nested,
+					Description: fieldDescription(v),
+				}
+			}
+			continue
+		}
+
+		description := fieldDescription(v)
+		constraints, err := parseFilterConstraints(v, tag)
+		if err != nil {
+			return 0, err
+		}
+		if constraints != nil {
+			if err := constraints.validate(v, t); err != nil {
+				return 0, err
+			}
+			description = strings.TrimSpace(description + " " + constraints.description())
+		}
+
+		// The operators are given in an object of the field, see WithFilterOperatorObjects
+		if options.filterOperatorObjects {
+			fields[name] = &graphql.InputObjectFieldConfig{
+				Type:        operatorInput(t, filterMap),
+				Description: description,
+			}
+			continue
+		}
+
+		addNullFilterField(fields, name, v.Type)
+		fields[name] = &graphql.InputObjectFieldConfig{
+			Type:        t,
+			Description: description,
+		}
+
+		// String fields can be matched against a regular expression as well
+		if t == graphql.String {
+			fields[name+regexFilterSuffix] = &graphql.InputObjectFieldConfig{
+				Type: regexScalar,
+			}
+		}
+
+		// Fields can be compared with operators like 'age_gt', see addFilterOperatorFields
+		addFilterOperatorFields(fields, name, t)
+	}
+	return len(fields), nil
+}
+
+// Returns the filter object of the struct type t for struct fields of that
+// type, e.g. dogs(where: {friend: {name: "Maru"}}). The filter objects are
+// shared by all fields of the type and named after it, e.g. 'DogFilter'.
+// Returns nil for structs that aren't exposed as objects and structs
+// without fields to filter by.
+func nestedFilterInput(t reflect.Type, filterMap map[string]graphql.ArgumentConfig, options *options) (graphql.Input, error) {
+	if !isNestedFilterType(t, options) {
+		return nil, nil
+	}
+
+	key := options.typeNamePart(t) + "Filter"
+	if known, ok := filterMap[key]; ok {
+		return known.Type, nil
+	}
+
+	n, err := createFilterInput(key, key, t, filterMap, options)
+	if err != nil || n == 0 {
+		delete(filterMap, key)
+		return nil, err
+	}
+	return filterMap[key].Type, nil
+}
+
+// Returns true if fields of type t are filtered by the fields of the struct,
+// see nestedFilterInput, rather than by a value.
+func isNestedFilterType(t reflect.Type, options *options) bool {
+	if _, ok := options.scalars[t]; ok {
+		return false
+	}
+	return t.Kind() == reflect.Struct && t.Name() != "" && t != typeTime && t != typeRegexp && !(options.protobuf && isProtoTimestamp(t))
+}
+
+// Returns a slice of the elements of the list r that match the filter.
+func filterList(r reflect.Value, filter map[string]any, options *options) reflect.Value {
+	matches := reflect.MakeSlice(reflect.SliceOf(r.Type().Elem()), 0, r.Len())
+	for i := 0; i < r.Len(); i++ {
+		if matchesFilter(r.Index(i), filter, options) {
+			matches = reflect.Append(matches, r.Index(i))
+		}
+	}
+	return matches
+}
+
+// Returns true if all fields of the filter match the given element and,
+// if the filter has alternatives in '_or', at least one of them does.
+// Nil pointers, as elements or as field values, never match, except for
+// the '_isNull' fields of nil field values.
+func matchesFilter(element reflect.Value, filter map[string]any, options *options) bool {
+	element, ok := indirectValue(element)
+	if !ok {
+		return false
+	}
+
+	for fieldName, filterValue := range filter {
+		if fieldName == orFilterField {
+			if alternatives, ok := filterValue.([]any); ok && !matchesAnyFilter(element, alternatives, options) {
+				return false
+			}
+			continue
+		}
+		if !matchesField(element, fieldName, filterValue, options) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns true if any of the filters matches the element. An empty
+// list of alternatives doesn't constrain the element.
+func matchesAnyFilter(element reflect.Value, filters []any, options *options) bool {
+	for _, filter := range filters {
+		if filter, ok := filter.(map[string]any); ok && matchesFilter(element, filter, options) {
+			return true
+		}
+	}
+	return len(filters) == 0
+}
+
+// Returns true if the field of the element matches the filter value.
+func matchesField(element reflect.Value, fieldName string, filterValue any, options *options) bool {
+	// Regular expressions are given for the field without the suffix
+	if _, ok := filterValue.(*regexp.Regexp); ok {
+		fieldName = strings.TrimSuffix(fieldName, regexFilterSuffix)
+	}
+
+	// Operators are given as suffix, unless the struct has a field of that name
+	operator := ""
+	field, ok := fieldByGraphqlName(element.Type(), fieldName)
+	if !ok {
+		fieldName, operator, ok = cutFilterOperator(fieldName)
+		if !ok {
+			return false
+		}
+		field, ok = fieldByGraphqlName(element.Type(), fieldName)
+		if !ok {
+			return false
+		}
+	}
+
+	val, err := options.fieldValue(element, field)
+	if err != nil {
+		return false
+	}
+
+	// Operator objects, see WithFilterOperatorObjects
+	if operators, ok := filterValue.(map[string]any); ok && !isNestedFilterType(indirectType(field.Type), options) {
+		return matchesOperators(val, operators)
+	}
+
+	// Nil fields only match '_isNull', see matchesNull
+	if operator == isNullFilterSuffix {
+		return matchesNull(val, filterValue)
+	}
+	val, ok = indirectValue(val)
+	if !ok {
+		return false
+	}
+
+	if operator != "" {
+		return matchesOperator(val, operator, filterValue)
+	}
+
+	// Struct fields are matched by the filter of their fields, see nestedFilterInput
+	if nested, ok := filterValue.(map[string]any); ok {
+		return val.Kind() == reflect.Struct && matchesFilter(val, nested, options)
+	}
+	return matchesValue(val, filterValue)
+}
+
+// Returns true if the field value equals the filter value.
+func matchesValue(val reflect.Value, filterValue any) bool {
+	var match bool
+	switch filterValue.(type) {
+	case float64, int, int64, uint64:
+		// If the filter value is a number, then it is of type float64 due to
+		// graphql.Float, see getBasicOutput, or of type int64 or uint64 for the
+		// Int64 and Uint64 scalars, see int64Scalar. Fields of any integer or
+		// float kind are compared by their value, including named types.
+		c, ok := compareNumber(val, filterValue)
+		match = ok && c == 0
+	case string:
+		fv := filterValue.(string)
+		switch {
+		case isByteSequence(val.Type()):
+			// Byte fields tagged with 'bytesAsString', see taggedOutput.
+			match = fv == string(byteSequence(val))
+		case val.Type() == typeDuration:
+			// Compared by value, so "90m" matches "1h30m"
+			d, err := time.ParseDuration(fv)
+			match = err == nil && int64(d) == val.Int()
+		case val.CanInt(), val.CanUint():
+			// Integer fields tagged with 'type=ID' or `json:",string"` are compared numerically, so "7" matches "07".
+			match = compareIDs(fv, val)
+		case val.CanFloat():
+			// Float fields tagged with `json:",string"`
+			f, err := strconv.ParseFloat(fv, 64)
+			match = err == nil && f == val.Float()
+		default:
+			match = fv == val.String()
+		}
+	case *regexp.Regexp:
+		re := filterValue.(*regexp.Regexp)
+		if isByteSequence(val.Type()) {
+			match = re.Match(byteSequence(val))
+		} else {
+			match = re.MatchString(filterString(val))
+		}
+	case bool:
+		// Fields of named bool types are compared by their value as well
+		match = val.Kind() == reflect.Bool && val.Bool() == filterValue.(bool)
+	case rune:
+		// Filter value of the Rune scalar, see taggedOutput.
+		match = val.CanInt() && val.Int() == int64(filterValue.(rune))
+	default:
+		match = filterValue == val.Interface()
+	}
+
+	return match
+}
+
+// Returns the string that string filters match a field value against, which is
+// the decimal representation of numbers tagged with `json:",string"`, the
+// String form of durations and the text of byte fields tagged with 'bytesAsString'.
+func filterString(val reflect.Value) string {
+	if isByteSequence(val.Type()) {
+		return string(byteSequence(val))
+	}
+	if val.Type() == typeDuration {
+		return time.Duration(val.Int()).String()
+	}
+	if isNumberKind(val.Kind()) {
+		return numberString(val)
+	}
+	return val.String()
+}
+
+// Returns the struct field with the given GraphQL name, see graphqlFieldName.
+func fieldByGraphqlName(t reflect.Type, name string) (reflect.StructField, bool) {
+	for _, field := range reflect.VisibleFields(t) {
+		if fieldName, visible := graphqlFieldName(field); visible && fieldName == name && isJSONField(t, field) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// Compares an ID filter value with an integer field numerically.
+func compareIDs(filterValue string, val reflect.Value) bool {
+	if val.CanInt() {
+		id, err := strconv.ParseInt(filterValue, 10, 64)
+		return err == nil && id == val.Int()
+	}
+
+	id, err := strconv.ParseUint(filterValue, 10, 64)
+	return err == nil && id == val.Uint()
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"cmp"
+	"reflect"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+// The suffixes of filter fields that compare numeric fields with the value:
+// cats(where: {age_gte: 2, age_lt: 5}) { name }
+const (
+	greaterFilterSuffix        = "_gt"
+	greaterOrEqualFilterSuffix = "_gte"
+	lessFilterSuffix           = "_lt"
+	lessOrEqualFilterSuffix    = "_lte"
+)
+
+// The suffixes of filter fields that match string fields by a substring:
+// cats(where: {name_startsWith: "Ma"}) { name }
+const (
+	containsFilterSuffix   = "_contains"
+	startsWithFilterSuffix = "_startsWith"
+)
+
+// The suffix of filter fields that match elements whose field doesn't equal the value.
+const notEqualFilterSuffix = "_ne"
+
+// The suffix of filter fields that match elements whose pointer, slice or map
+// field is nil, or isn't nil for false: dogs(where: {owner_isNull: true}) { name }
+const isNullFilterSuffix = "_isNull"
+
+var filterOperatorSuffixes = []string{
+	greaterFilterSuffix, greaterOrEqualFilterSuffix, lessFilterSuffix, lessOrEqualFilterSuffix,
+	containsFilterSuffix, startsWithFilterSuffix, notEqualFilterSuffix, isNullFilterSuffix,
+}
+
+// The field of operator objects that matches elements whose field equals the value.
+const equalOperator = "eq"
+
+// Takes the operators of 'where' filters as an input object of each field
+// instead of as fields with a suffix:
+//
+//	cats(where: {age: {gte: 2, lt: 5}, name: {startsWith: "Ma"}}) { name }
+//
+// The operators are named like the suffixes without the underscore, e.g.
+// 'gt', 'startsWith', 'regex' and 'isNull', and 'eq' compares with the value
+// like the field does without this option. The objects are shared by all
+// fields of a type and named after it, e.g. 'FloatOperators'. Struct fields
+// are still filtered by the fields of the struct, and slices, maps and
+// pointers to structs keep their '_isNull' field next to them.
+func WithFilterOperatorObjects() Option {
+	return func(o *options) {
+		o.filterOperatorObjects = true
+	}
+}
+
+// Returns the suffixes of the operators that apply to filter fields of type t.
+func filterOperators(t graphql.Output) []string {
+	suffixes := []string{notEqualFilterSuffix}
+	switch t {
+	case graphql.Float, graphql.Int, int64Scalar, uint64Scalar:
+		suffixes = append(suffixes, greaterFilterSuffix, greaterOrEqualFilterSuffix, lessFilterSuffix, lessOrEqualFilterSuffix)
+	case graphql.String:
+		suffixes = append(suffixes, containsFilterSuffix, startsWithFilterSuffix)
+	}
+	return suffixes
+}
+
+// Adds the operator fields of the filter field with the given name to the
+// fields of a filter object. t is the type of the field and its filter values.
+// Fields of the struct take precedence over operator fields of the same name.
+func addFilterOperatorFields(fields graphql.InputObjectConfigFieldMap, name string, t graphql.Output) {
+	for _, suffix := range filterOperators(t) {
+		if _, ok := fields[name+suffix]; !ok {
+			fields[name+suffix] = &graphql.InputObjectFieldConfig{Type: t}
+		}
+	}
+}
+
+// Returns the operator object of filter fields of type t, see
+// WithFilterOperatorObjects, and registers it in the filterMap.
+func operatorInput(t graphql.Output, filterMap map[string]graphql.ArgumentConfig) graphql.Input {
+	name := t.Name() + "Operators"
+	if known, ok := filterMap[name]; ok {
+		return known.Type
+	}
+
+	fields := graphql.InputObjectConfigFieldMap{
+		equalOperator: &graphql.InputObjectFieldConfig{Type: t},
+		strings.TrimPrefix(isNullFilterSuffix, "_"): &graphql.InputObjectFieldConfig{Type: graphql.Boolean},
+	}
+	for _, suffix := range filterOperators(t) {
+		fields[strings.TrimPrefix(suffix, "_")] = &graphql.InputObjectFieldConfig{Type: t}
+	}
+	if t == graphql.String {
+		fields[strings.TrimPrefix(regexFilterSuffix, "_")] = &graphql.InputObjectFieldConfig{Type: regexScalar}
+	}
+
+	input := graphql.NewInputObject(graphql.InputObjectConfig{Name: name, Fields: fields})
+	filterMap[name] = graphql.ArgumentConfig{Type: input}
+	return input
+}
+
+// Returns true if the field value satisfies all operators of an operator
+// object, see WithFilterOperatorObjects. The operators are named like the
+// suffixes without the underscore. Nil values only match 'isNull'.
+func matchesOperators(val reflect.Value, operators map[string]any) bool {
+	for operator, filterValue := range operators {
+		suffix := "_" + operator
+
+		var match bool
+		if suffix == isNullFilterSuffix {
+			match = matchesNull(val, filterValue)
+		} else if val, ok := indirectValue(val); ok {
+			switch {
+			case operator == equalOperator, suffix == regexFilterSuffix:
+				// Regular expressions are matched like the values of '_regex' fields
+				match = matchesValue(val, filterValue)
+			default:
+				match = matchesOperator(val, suffix, filterValue)
+			}
+		}
+		if !match {
+			return false
+		}
+	}
+	return true
+}
+
+// Adds the '_isNull' field to the fields of a filter object if the field of
+// type t can be nil. Fields of the struct take precedence over it.
+func addNullFilterField(fields graphql.InputObjectConfigFieldMap, name string, t reflect.Type) {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map:
+		if _, ok := fields[name+isNullFilterSuffix]; !ok {
+			fields[name+isNullFilterSuffix] = &graphql.InputObjectFieldConfig{Type: graphql.Boolean}
+		}
+	}
+}
+
+// Returns true if the nilness of the field value is the one asked for by
+// the filter value of an '_isNull' field. Pointers to nil pointers are nil.
+func matchesNull(val reflect.Value, filterValue any) bool {
+	isNull, ok := filterValue.(bool)
+	if !ok {
+		return false
+	}
+	val, ok = indirectValue(val)
+	nilValue := !ok || (val.Kind() == reflect.Slice || val.Kind() == reflect.Map) && val.IsNil()
+	return nilValue == isNull
+}
+
+// Splits the name of an operator filter field into the name of the
+// filtered field and the suffix of the operator, e.g. 'age_gt' into
+// 'age' and '_gt'. Returns false if the name has no operator suffix.
+func cutFilterOperator(name string) (string, string, bool) {
+	for _, suffix := range filterOperatorSuffixes {
+		if fieldName, ok := strings.CutSuffix(name, suffix); ok {
+			return fieldName, suffix, true
+		}
+	}
+	return name, "", false
+}
+
+// Returns true if the field value satisfies the operator with the filter value.
+func matchesOperator(val reflect.Value, operator string, filterValue any) bool {
+	switch operator {
+	case notEqualFilterSuffix:
+		return !matchesValue(val, filterValue)
+	case containsFilterSuffix:
+		s, ok := filterValue.(string)
+		return ok && isStringFilterable(val) && strings.Contains(filterString(val), s)
+	case startsWithFilterSuffix:
+		s, ok := filterValue.(string)
+		return ok && isStringFilterable(val) && strings.HasPrefix(filterString(val), s)
+	}
+
+	c, ok := compareNumber(val, filterValue)
+	if !ok {
+		return false
+	}
+	switch operator {
+	case greaterFilterSuffix:
+		return c > 0
+	case greaterOrEqualFilterSuffix:
+		return c >= 0
+	case lessFilterSuffix:
+		return c < 0
+	case lessOrEqualFilterSuffix:
+		return c <= 0
+	}
+	return false
+}
+
+// Compares a numeric field value with a filter value, which is a float64 for
+// Float fields, an int for Int fields or an int64 or uint64 for the Int64
+// and Uint64 scalars. Returns false if the two can't be compared.
+func compareNumber(val reflect.Value, filterValue any) (int, bool) {
+	switch fv := filterValue.(type) {
+	case float64:
+		switch {
+		case val.CanInt():
+			return cmp.Compare(float64(val.Int()), fv), true
+		case val.CanUint():
+			return cmp.Compare(float64(val.Uint()), fv), true
+		case val.CanFloat():
+			return cmp.Compare(val.Float(), fv), true
+		}
+	case int:
+		// Values of Int fields, see WithSmallInts
+		switch {
+		case val.CanInt():
+			return cmp.Compare(val.Int(), int64(fv)), true
+		case val.CanUint() && fv < 0:
+			return 1, true
+		case val.CanUint():
+			return cmp.Compare(val.Uint(), uint64(fv)), true
+		}
+	case int64:
+		if val.CanInt() {
+			return cmp.Compare(val.Int(), fv), true
+		}
+	case uint64:
+		if val.CanUint() {
+			return cmp.Compare(val.Uint(), fv), true
+		}
+	}
+	return 0, false
+}
+
+// Returns true for strings, numbers tagged with `json:",string"` and byte
+// fields tagged with 'bytesAsString', which are matched by the string
+// operators, see filterString.
+func isStringFilterable(val reflect.Value) bool {
+	return val.Kind() == reflect.String || isNumberKind(val.Kind()) || isByteSequence(val.Type())
+}
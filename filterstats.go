@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Reports for every list or map field filtered with 'where' how many elements
+// matched the filter out of the total in 'extensions.filterStats' of the result,
+// keyed by the path of the field:
+//
+//	"extensions": { "filterStats": { "kennel.dogs": { "matched": 2, "total": 10 } } }
+func WithFilterStats() Option {
+	return func(o *options) {
+		o.filterStats = true
+	}
+}
+
+type filterStat struct {
+	Matched int `json:"matched"`
+	Total   int `json:"total"`
+}
+
+// The key of the filterStats of a query in its context.
+type filterStatsKey struct{}
+
+// Collects the filterStat of every filtered field while a query is executed.
+type filterStats struct {
+	mutex sync.Mutex
+	stats map[string]filterStat
+}
+
+// Returns the collector of the query, or nil if the stats are disabled.
+func filterStatsFrom(ctx context.Context) *filterStats {
+	if ctx == nil {
+		return nil
+	}
+	stats, _ := ctx.Value(filterStatsKey{}).(*filterStats)
+	return stats
+}
+
+// Records the stat of the field at the path of the resolve params.
+func (s *filterStats) record(p graphql.ResolveParams, matched, total int) {
+	var path []string
+	if p.Info.Path != nil {
+		for _, key := range p.Info.Path.AsArray() {
+			path = append(path, fmt.Sprint(key))
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.stats[strings.Join(path, ".")] = filterStat{Matched: matched, Total: total}
+}
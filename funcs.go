@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/graphql-go/graphql"
+)
+
+var typeContext = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// The calling convention of a function field. The parameters are all optional,
+// but have to be in this order:
+//
+//	func(ctx context.Context, self Dog, args DogArgs) (R, error)
+//
+// ctx receives the context of the query, self is the struct value that declares
+// the field and the exported fields of the args struct are exposed as arguments
+// of the field. If the args struct has a 'Validate() error' method, it is
+// called before the function, and its error fails the field. The function
+// returns the value R of the field, optionally followed by an error.
+type funcSignature struct {
+	hasContext bool
+	hasSelf    bool
+	args       reflect.Type
+	hasError   bool
+}
+
+// Returns the calling convention of a function field of the owner struct,
+// or an error if the function doesn't follow it.
+func parseFuncSignature(t, owner reflect.Type) (funcSignature, error) {
+	var s funcSignature
+
+	i := 0
+	if i < t.NumIn() && t.In(i) == typeContext {
+		s.hasContext = true
+		i++
+	}
+	if i < t.NumIn() && t.In(i) == owner {
+		s.hasSelf = true
+		i++
+	}
+	if i < t.NumIn() && t.In(i).Kind() == reflect.Struct {
+		s.args = t.In(i)
+		i++
+	}
+	if i < t.NumIn() {
+		return s, fmt.Errorf("parameter %d of type %s is not supported", i+1, t.In(i))
+	}
+
+	switch {
+	case t.NumOut() == 1:
+	case t.NumOut() == 2 && t.Out(1) == typeError:
+		s.hasError = true
+	default:
+		return s, fmt.Errorf("must return a value, optionally followed by an error")
+	}
+	return s, nil
+}
+
+// Creates the GraphQL arguments of the fields of the args struct.
+func (s funcSignature) createArguments(options *options) (graphql.FieldConfigArgument, error) {
+	args := graphql.FieldConfigArgument{}
+	if s.args == nil {
+		return args, nil
+	}
+
+	for _, field := range reflect.VisibleFields(s.args) {
+		if !isArgumentField(field) {
+			continue
+		}
+
+		input := options.basicOutput(field.Type)
+		enum, err := options.enum(field.Type)
+		if err != nil {
+			return nil, err
+		}
+		if enum != nil {
+			input = enum.output
+		}
+		if input == nil {
+			return nil, fmt.Errorf("argument %s of type %s is not supported", field.Name, field.Type)
+		}
+
+		args[argumentName(field)] = &graphql.ArgumentConfig{Type: input, Description: fieldDescription(field)}
+	}
+	return args, nil
+}
+
+// Returns true if the field of an args struct is exposed as an argument.
+// Like struct fields, fields tagged with `graphql:"-"` or `json:"-"` are
+// hidden, and so are fields named '-' with `json:"-,"`.
+func isArgumentField(field reflect.StructField) bool {
+	name, visible := graphqlFieldName(field)
+	return field.IsExported() && !field.Anonymous && visible && name != "-"
+}
+
+// Returns the GraphQL name of a field of an args struct, which is named
+// like struct fields, see graphqlFieldName: by the graphql tag, the json
+// tag or the lowercased field name.
+func argumentName(field reflect.StructField) string {
+	name, _ := graphqlFieldName(field)
+	return name
+}
+
+// Calls the function with the parameters of its signature
+// and returns its value or error.
+func (s funcSignature) call(fn reflect.Value, p graphql.ResolveParams) (reflect.Value, error) {
+	if err := canceled(p); err != nil {
+		return reflect.Value{}, err
+	}
+
+	var in []reflect.Value
+	if s.hasContext {
+		ctx := p.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		in = append(in, reflect.ValueOf(ctx))
+	}
+	if s.hasSelf {
+		in = append(in, reflect.ValueOf(p.Source))
+	}
+	if s.args != nil {
+		args, err := argumentsStruct(s.args, p.Args)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if err := validateArguments(args); err != nil {
+			return reflect.Value{}, err
+		}
+		in = append(in, args)
+	}
+
+	results := fn.Call(in)
+	if s.hasError && !results[1].IsNil() {
+		return reflect.Value{}, results[1].Interface().(error)
+	}
+	return results[0], nil
+}
+
+// Returns a copy of args without the arguments in omitted.
+func omitArguments(args map[string]any, omitted graphql.FieldConfigArgument) map[string]any {
+	if len(omitted) == 0 {
+		return args
+	}
+	remaining := make(map[string]any, len(args))
+	for name, value := range args {
+		if _, ok := omitted[name]; !ok {
+			remaining[name] = value
+		}
+	}
+	return remaining
+}
+
+// Returns the error of the query context once it is canceled, so that
+// functions and methods aren't called for a query nobody waits for.
+func canceled(p graphql.ResolveParams) error {
+	if p.Context == nil {
+		return nil
+	}
+	return p.Context.Err()
+}
+
+// Implemented by args structs that validate their arguments before the function is called.
+type argumentsValidator interface {
+	Validate() error
+}
+
+// Calls the Validate method of the args struct if it has one, with a value
+// or a pointer receiver. Its error is returned as the error of the field.
+func validateArguments(args reflect.Value) error {
+	if validator, ok := args.Addr().Interface().(argumentsValidator); ok {
+		return validator.Validate()
+	}
+	return nil
+}
+
+// Fills a new value of the args struct type with the given arguments.
+// Fields without an argument keep their zero value.
+func argumentsStruct(t reflect.Type, args map[string]any) (reflect.Value, error) {
+	r := reflect.New(t).Elem()
+	for _, field := range reflect.VisibleFields(t) {
+		if !isArgumentField(field) {
+			continue
+		}
+
+		name := argumentName(field)
+		value, ok := args[name]
+		if !ok || value == nil {
+			continue
+		}
+
+		v := reflect.ValueOf(value)
+		if !v.CanConvert(field.Type) {
+			return reflect.Value{}, fmt.Errorf("argument %s can't be converted to %s", name, field.Type)
+		}
+
+		// Integers are passed as Float, see getBasicOutput
+		if f, ok := value.(float64); ok && (field.Type.Kind() != reflect.Float32 && field.Type.Kind() != reflect.Float64) && f != math.Trunc(f) {
+			return reflect.Value{}, fmt.Errorf("argument %s must be an integer", name)
+		}
+
+		r.FieldByIndex(field.Index).Set(v.Convert(field.Type))
+	}
+	return r, nil
+}
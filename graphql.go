@@ -1,9 +1,14 @@
 package main
 
 import (
+	"context"
+	"encoding"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"reflect"
+	"regexp"
 	"strings"
 	"time"
 
@@ -12,16 +17,44 @@ import (
 )
 
 var typeTime = reflect.TypeOf(time.Time{})
+var typeDuration = reflect.TypeOf(time.Duration(0))
+var typeError = reflect.TypeOf((*error)(nil)).Elem()
+var typeRegexp = reflect.TypeOf(regexp.Regexp{})
+
+// Methods of the interfaces that types implement to encode or format their
+// values, e.g. String of fmt.Stringer, which aren't exposed as fields.
+var interfaceMethods = map[string]reflect.Type{
+	"MarshalJSON": reflect.TypeOf((*json.Marshaler)(nil)).Elem(),
+	"MarshalText": reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem(),
+	"String":      reflect.TypeOf((*fmt.Stringer)(nil)).Elem(),
+	"Error":       typeError,
+}
 
 type Pair[T1 any, T2 any] struct {
 	First  T1
 	Second T2
 }
 
-func executeQuery(query string, schema graphql.Schema) (*graphql.Result, error) {
+// Returns a context for the execution of a query, which carries the
+// state collected by the resolvers, see objectSources and filterStats.
+func newQueryContext(options *options) context.Context {
+	ctx := options.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx = context.WithValue(ctx, objectSourcesKey{}, &objectSources{results: map[string]objectSource{}})
+	if options.filterStats {
+		ctx = context.WithValue(ctx, filterStatsKey{}, &filterStats{stats: map[string]filterStat{}})
+	}
+	return ctx
+}
+
+func executeQuery(ctx context.Context, query string, variables map[string]any, schema graphql.Schema) (*graphql.Result, error) {
 	result := graphql.Do(graphql.Params{
-		Schema:        schema,
-		RequestString: query,
+		Schema:         schema,
+		RequestString:  query,
+		VariableValues: variables,
+		Context:        ctx,
 	})
 	if len(result.Errors) > 0 {
 		return nil, result.Errors[0].OriginalError()
@@ -45,8 +78,12 @@ func getBasicOutput(t reflect.Type) graphql.Output {
 		// https://github.com/graphql/graphql-spec/issues/73
 		return graphql.Float
 
-	case reflect.Int64, reflect.Uint64:
-		return graphql.Float
+	case reflect.Int64:
+		// Float can't represent all 64-bit integers exactly, see int64Scalar
+		return int64Scalar
+
+	case reflect.Uint64:
+		return uint64Scalar
 
 	case reflect.Bool:
 		return graphql.Boolean
@@ -58,11 +95,107 @@ func getBasicOutput(t reflect.Type) graphql.Output {
 	}
 }
 
+// Returns the output type from getBasicOutput, or Int for small integers, see WithSmallInts.
+func (o *options) basicOutput(t reflect.Type) graphql.Output {
+	if o.smallInts && isSmallInt(t.Kind()) {
+		return graphql.Int
+	}
+	return getBasicOutput(t)
+}
+
+// Returns true for the integer kinds that fit into GraphQL's Int.
+func isSmallInt(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return true
+	}
+	return false
+}
+
+// Returns true for []byte and [N]byte types.
+func isByteSequence(t reflect.Type) bool {
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() == reflect.Uint8
+}
+
+// Returns true for slices and arrays of lists, e.g. [][]int or [][]byte.
+func isNestedList(t reflect.Type) bool {
+	if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+		return false
+	}
+	elem := indirectType(t.Elem())
+	return elem.Kind() == reflect.Slice || elem.Kind() == reflect.Array
+}
+
+// Returns the bytes of a []byte or [N]byte value.
+func byteSequence(r reflect.Value) []byte {
+	if r.Kind() == reflect.Array {
+		// Arrays are not addressable if they are part of a
+		// struct value, so the bytes have to be copied.
+		b := make([]byte, r.Len())
+		reflect.Copy(reflect.ValueOf(b), r)
+		return b
+	}
+	return r.Bytes()
+}
+
+// Converts a reflected value into the representation
+// expected by the output type from getBasicOutput.
+func resolveValue(r reflect.Value, options *options) (any, error) {
+	if r.Type() == typeDuration {
+		// Human-readable like "1h30m0s" instead of nanoseconds
+		return time.Duration(r.Int()).String(), nil
+	}
+	if options.smallInts && isSmallInt(r.Kind()) {
+		// Int, see WithSmallInts
+		if r.CanInt() {
+			return int(r.Int()), nil
+		}
+		return int(r.Uint()), nil
+	}
+
+	switch r.Kind() {
+	case reflect.Int64:
+		// Exact, see int64Scalar
+		return r.Int(), nil
+
+	case reflect.Uint64:
+		return r.Uint(), nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		// Use float since graphql int is limited to 32-bit.
+		// Check getBasicOutput() for more info.
+		return float64(r.Int()), nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		// Use float since graphql int is limited to 32-bit.
+		// Check getBasicOutput() for more info.
+		return float64(r.Uint()), nil
+
+	case reflect.Bool:
+		return r.Bool(), nil
+
+	case reflect.Float32, reflect.Float64:
+		return r.Float(), nil
+	case reflect.String:
+		return r.Interface(), nil
+	case reflect.Struct:
+
+		switch r.Type() {
+		case typeTime:
+			return options.formatTime(r.Interface().(time.Time)), nil
+		}
+
+		return r.Interface(), nil
+	}
+
+	return nil, errors.New("unknown type")
+}
+
 // Browses through the members of a given type and creates
 // the corresponding field and output structure of given type.
 // As an oversimplification, it works similarly to json.Marshal
 // but for GraphQL.
-func createGraphQlFieldHierarchy(t reflect.Type, typesMap map[string]Pair[graphql.Output, graphql.Fields], filterMap map[string]graphql.ArgumentConfig) (graphql.Output, graphql.Fields) {
+func createGraphQlFieldHierarchy(t reflect.Type, path []string, typesMap map[string]Pair[graphql.Output, graphql.Fields], filterMap map[string]graphql.ArgumentConfig, options *options) (graphql.Output, graphql.Fields, error) {
 
 	// GraphQL complains when a type with the same name is registered once. Error:
 	// Schema must contain uniquely named types but contains multiple types named "XYZ".
@@ -82,35 +215,155 @@ func createGraphQlFieldHierarchy(t reflect.Type, typesMap map[string]Pair[graphq
 		filterMap = make(map[string]graphql.ArgumentConfig, 0)
 	}
 
-	knownType, ok := typesMap[t.Name()]
-	if ok {
-		return knownType.First, knownType.Second
+	if options == nil {
+		options = newOptions(nil)
+	}
+
+	// Pointers are exposed like the type they point to, nil pointers resolve to null.
+	if t.Kind() == reflect.Pointer {
+		if t == typeSyncMapPointer {
+			// Unregistered sync.Map fields are skipped, see WithSyncMap
+			return nil, nil, nil
+		}
+		return createGraphQlFieldHierarchy(t.Elem(), path, typesMap, filterMap, options)
+	}
+
+	// Objects are named after their Go type unless renamed, see WithTypeName
+	typeName := t.Name()
+	if t.Kind() == reflect.Struct {
+		typeName = options.objectName(t, path)
+		if err := options.checkTypeName(typeName, t); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Other types named like an object, e.g. time.Duration
+	// and a Duration struct, are not that object
+	knownType, ok := typesMap[typeName]
+	if ok && options.typeOwners[typeName] == t {
+		return knownType.First, knownType.Second, nil
+	}
+
+	enum, err := options.enum(t)
+	if err != nil {
+		return nil, nil, err
+	}
+	if enum != nil {
+		return enum.output, nil, nil
+	}
+
+	// Registered scalars take precedence over the derived types, see WithScalar
+	if scalar, ok := options.scalars[t]; ok {
+		return scalar, nil, nil
+	}
+	if options.protobuf && isProtoTimestamp(t) {
+		return protoTimestampScalar, nil, nil
+	}
+
+	if types, ok := options.mergedStructs[t]; ok {
+		return createMergedObject(t, types, path, typesMap, filterMap, options)
+	}
+
+	if members, ok := options.unions[t]; ok {
+		return createUnion(t, members, path, typesMap, filterMap, options)
 	}
 
 	// The code automatically transforms some types, such as time.Time, because their structure is unnecessarily complex
 	// for GraphQL output. For instance, the 'loc' in time.Time isn't needed and the type can be a simple timestamp.
 	switch t {
 	case typeTime:
-		// Return float due to the 32-bit limitations of ints
-		return graphql.Float, nil
+		// See WithTimeFormat
+		return options.timeOutput(), nil, nil
+	case typeRegexp:
+		// Compiled patterns are exposed as their source pattern
+		return regexScalar, nil, nil
+	case typeDuration:
+		// Durations are exposed like "1h30m0s" instead of as nanoseconds,
+		// graphql-go formats them with their String method
+		return graphql.String, nil, nil
+	case typeAny, typeRawMessage, typeJSONObject:
+		// Opaque JSON payloads are returned as they are, see jsonScalar
+		return jsonScalar, nil, nil
 	}
 
 	switch t.Kind() {
+	case reflect.Chan:
+		// Channels are streams of values and would have to be drained to be
+		// queried, which is ambiguous for a query. They are reserved for
+		// subscriptions and never show up in the query schema.
+		return nil, nil, nil
 	case reflect.Func:
+		if t.NumOut() == 0 {
+			return nil, nil, nil
+		}
+
 		// Retrieve the return type of the function
 		returnType := t.Out(0)
-		if returnType.Kind() == reflect.Interface {
-			// Return type must be explicitly defined, no interface/any allowed
+		if returnType.Kind() == reflect.Interface && returnType != typeAny && options.unions[returnType] == nil {
+			// Return type must be explicitly defined, no interface allowed
 			// as the type is used to generate the GraphQL schema.
-			return nil, nil
+			// Interfaces registered as union and 'any', which is
+			// returned as JSON, are the only exceptions.
+			return nil, nil, nil
+		}
+
+		if returnType.Kind() == reflect.Chan {
+			// Same as channel fields, see above.
+			return nil, nil, nil
 		}
 
-		structFieldType, fields := createGraphQlFieldHierarchy(returnType, typesMap, filterMap)
-		return structFieldType, fields
+		structFieldType, fields, err := createGraphQlFieldHierarchy(returnType, path, typesMap, filterMap, options)
+		return structFieldType, fields, err
 	case reflect.Struct:
 
+		// Objects nested deeper than the configured build depth are omitted
+		// to bound the size of the schema for deep or pathological type graphs.
+		if options.maxBuildDepth > 0 && len(path)-1 > options.maxBuildDepth {
+			options.logf("graphql: omitting %s, it exceeds the max build depth of %d", strings.Join(path, "."), options.maxBuildDepth)
+			return nil, nil, nil
+		}
+
+		if err := options.claimTypeName(typeName, t); err != nil {
+			return nil, nil, err
+		}
+
 		fields := graphql.Fields{}
 
+		// Register the object before its fields are built, otherwise
+		// recursive types would recurse forever before reaching the
+		// typesMap check above:
+		// type Category struct {
+		//		Children []Category <-- Refers to the object that is still being built.
+		// }
+		//
+		// The fields are handed to graphql-go as a thunk which is only evaluated once the
+		// schema is created. At that point the 'fields' map below is completely filled.
+		//
+		// Reflecting the fields inside the thunk instead wouldn't make building cheaper
+		// for queries that select few fields: graphql.NewSchema evaluates the thunks of
+		// all reachable objects to collect its type map, regardless of the query. For a
+		// struct with 100 fields and three levels of 3 child lists, reflecting inside the
+		// thunk of the root object takes as long as the eager build, about 4-6ms, of which
+		// the reflection is about 1.8ms, see BenchmarkBuildSchema.
+		o := graphql.NewObject(graphql.ObjectConfig{
+			Name:        typeName,
+			Description: typeDescription(t),
+			Fields: graphql.FieldsThunk(func() graphql.Fields {
+				return fields
+			}),
+		})
+
+		typesMap[typeName] = Pair[graphql.Output, graphql.Fields]{First: o, Second: fields}
+
+		// Maps the Go field names to their GraphQL field names
+		fieldNames := map[string]string{}
+
+		// Methods that serve as accessors of unexported fields, see WithAccessor
+		accessorMethods := map[string]bool{}
+
+		// Go field names of the lists wrapped in a page object
+		paginatedFields := map[string]bool{}
+
 		for _, structField := range reflect.VisibleFields(t) {
 			// Subfields are fields from struct subtypes.
 			// E.g:
@@ -129,89 +382,183 @@ func createGraphQlFieldHierarchy(t reflect.Type, typesMap map[string]Pair[graphq
 			//		X
 			// }
 			//
-			structFieldType, subfields := createGraphQlFieldHierarchy(structField.Type, typesMap, filterMap)
+			tag := parseFieldTag(structField)
+			fieldType := structField.Type
 
-			// Skip unsupported types
-			if structFieldType == nil {
+			// Reflection can't read the values of unexported fields,
+			// they are only exposed through a registered accessor.
+			accessor, hasAccessor, err := options.accessor(t, structField)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !structField.IsExported() && !hasAccessor || options.skipProtoField(structField) {
+				continue
+			}
+			if hasAccessor {
+				accessorMethods[accessor] = true
+			}
+
+			// Fields hidden from encoding/json or with `graphql:"-"` are hidden from
+			// GraphQL, embedded structs are flattened into the object
+			name, visible := graphqlFieldName(structField)
+			if !visible || !isJSONField(t, structField) {
+				continue
+			}
+			if name == "-" {
+				// Named '-' by `json:"-,"` like in encoding/json, which GraphQL can't express
+				options.logf("graphql: skipping field %s of %s: its json name \"-\" is not a valid GraphQL name, name it with the graphql tag", structField.Name, typeName)
+				continue
+			}
+			if !graphqlName.MatchString(name) {
+				return nil, nil, fmt.Errorf("name %q of field %s of %s is not a valid GraphQL name", name, structField.Name, typeName)
+			}
+
+			// Filter-only fields are part of the 'where' input of lists, but not of the object
+			if tag.has("filterOnly") {
+				if tag.has("outputOnly") {
+					return nil, nil, fmt.Errorf("field %s of %s can't be both filterOnly and outputOnly", structField.Name, typeName)
+				}
 				continue
 			}
 
-			args := graphql.FieldConfigArgument{}
+			// sync.Map fields are untyped, they are exposed like the map type registered for them
+			syncMapType, isSyncMap := options.syncMaps[syncMapField{owner: t, field: structField.Name}]
+			if isSyncMap {
+				if fieldType != typeSyncMapPointer {
+					return nil, nil, fmt.Errorf("field %s of %s must be of type *sync.Map", structField.Name, typeName)
+				}
+				if syncMapType.Kind() != reflect.Map {
+					return nil, nil, fmt.Errorf("type registered for sync.Map field %s of %s must be a map", structField.Name, typeName)
+				}
+				fieldType = syncMapType
+			}
 
-			// Register all filter arguments
-			for k, v := range subfields {
-				switch v.Type {
-				case graphql.String, graphql.Int, graphql.Boolean, graphql.Float:
-					args[k] = &graphql.ArgumentConfig{
-						Type: v.Type,
-					}
+			// Function fields get the arguments of their return type and the fields of
+			// their args struct, see funcSignature. Functions with other signatures,
+			// e.g. without a result, fail even if their result type isn't supported.
+			var signature funcSignature
+			if fieldType.Kind() == reflect.Func {
+				signature, err = parseFuncSignature(fieldType, t)
+				if err != nil {
+					return nil, nil, fmt.Errorf("function field %s of %s: %w", structField.Name, typeName, err)
 				}
 			}
 
+			structFieldType, subfields, err := createGraphQlFieldHierarchy(fieldType, appendPath(path, structField.Name), typesMap, filterMap, options)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			// Struct tags can override the type of basic fields
+			tagged := taggedOutput(structField, tag)
+			if tagged != nil {
+				structFieldType, subfields = tagged, nil
+			}
+
+			// Skip unsupported types
+			if structFieldType == nil {
+				continue
+			}
+
 			// Value copy to ensure proper capturing of variable in Resolve closure.
 			// https://eli.thegreenplace.net/2019/go-internals-capturing-loop-variables-in-closures/
+			reflectedField := structField
 			structFieldName := structField.Name
 			structFieldTypeKind := structField.Type.Kind()
 
-			switch structFieldTypeKind {
-			// Add helper paramters to graphql lists
-			case reflect.Slice, reflect.Array:
+			// Function fields get the arguments of the type they return
+			valueType := fieldType
+			if structFieldTypeKind == reflect.Func {
+				valueType = valueType.Out(0)
+			}
+			valueType = indirectType(valueType)
 
-				// Add where filter if the array or slice contains structs
-				if structField.Type.Elem().Kind() == reflect.Struct {
+			// Go field names are case-sensitive, GraphQL field names are lowercased,
+			// so e.g. 'ID' and 'Id' both end up as 'id'. Json tags can collide as well.
+			fieldName := name
+			if collidingField, ok := fields[fieldName]; ok {
+				switch options.nameCollisionPolicy {
+				case NameCollisionError:
+					return nil, nil, fmt.Errorf("fields %s and %s of %s both map to the GraphQL field %q", collidingField.Name, structFieldName, typeName, fieldName)
+				case NameCollisionFirstWins:
+					continue
+				case NameCollisionSuffix:
+					fieldName = suffixedFieldName(fields, fieldName)
+				}
+			}
+			fieldNames[structFieldName] = fieldName
+			if structFieldTypeKind == reflect.Func {
+				options.addReflectedField(typeName, fieldName, structField.Type, funcField)
+			} else {
+				options.addReflectedField(typeName, fieldName, structField.Type, staticField)
+			}
 
-					// Example syntax:
-					// items (where: {X: "abc"}) { X }
-					// If the filter object contains multiple fields,
-					// the filter will be applied as an OR operation.
-					// Meaning, the first item that matches any value
-					// of the fields will be returned.
+			// Blobs are base64 strings like all byte fields, but can be downloaded via ServeBlob
+			if tag.has("blob") {
+				if !isByteSequence(valueType) || tagged != nil {
+					return nil, nil, fmt.Errorf("field %s of %s is tagged as blob but doesn't resolve to bytes", structFieldName, typeName)
+				}
+				if options.blobs == nil {
+					options.blobs = map[schemaField]string{}
+				}
+				options.blobs[schemaField{typeName: typeName, fieldName: fieldName}] = tag.blobContentType()
+			}
 
-					argConfig, ok := filterMap[structFieldName]
-					if !ok {
-						fields := graphql.InputObjectConfigFieldMap{}
-
-						for _, v := range reflect.VisibleFields(structField.Type.Elem()) {
-							t := getBasicOutput(v.Type)
-							if t != nil {
-								fields[strings.ToLower(v.Name)] = &graphql.InputObjectFieldConfig{
-									Type: t,
-								}
-							}
-						}
+			// Lists of structs can be wrapped in a connection or a page object,
+			// see WithConnections and WithPaginatedLists
+			connection := tagged == nil && options.connection(valueType, tag)
+			paginated := tagged == nil && !connection && options.paginated(valueType, tag)
+			var args graphql.FieldConfigArgument
+			if connection {
+				structFieldType = createConnectionObject(valueType, structFieldType, typesMap, options)
+				args, err = createConnectionArguments(structFieldName, valueType, filterMap, options)
+			} else if paginated {
+				structFieldType = createPageObject(valueType, structFieldType, typesMap, options)
+				args, err = createPageArguments(structFieldName, valueType, filterMap, options)
+				paginatedFields[structFieldName] = true
+			} else {
+				args, err = createFieldArguments(structFieldName, valueType, subfields, filterMap, options)
+			}
+			if err != nil {
+				return nil, nil, err
+			}
 
-						argConfig = graphql.ArgumentConfig{
-							Type: graphql.NewInputObject(graphql.InputObjectConfig{
-								Name:   strings.ToLower(structFieldName),
-								Fields: fields,
-							}),
-						}
+			funcArgs, err := signature.createArguments(options)
+			if err != nil {
+				return nil, nil, fmt.Errorf("function field %s of %s: %w", structField.Name, typeName, err)
+			}
+			for name, arg := range funcArgs {
+				// Functions may paginate themselves, then their own 'skip', 'limit',
+				// 'first' and 'last' arguments replace the generated ones, see omitArguments
+				_, ok := args[name]
+				if ok && name != options.skipArg && name != options.limitArg && name != firstArg && name != lastArg {
+					return nil, nil, fmt.Errorf("function field %s of %s: argument %q collides with a generated argument", structField.Name, typeName, name)
+				}
+				args[name] = arg
+			}
 
-						filterMap[structFieldName] = argConfig
-						ok = true
-					}
-					if ok {
-						args["where"] = &argConfig
-					}
-				} else {
-					// Add skip filter
-					args["skip"] = &graphql.ArgumentConfig{
-						Type: graphql.Int,
+			// Like encoding/json, empty values of fields tagged with `json:",omitempty"` are
+			// null. Non-null fields, page objects and connections keep their values.
+			omitEmpty := hasJSONOption(structField, "omitempty") && structFieldTypeKind != reflect.Func && !tag.has("nonnull") && !connection && !paginated
+
+			field := &graphql.Field{
+				Name:              structField.Name,
+				Type:              structFieldType,
+				Args:              args,
+				Description:       fieldDescription(structField),
+				DeprecationReason: tag.deprecationReason(),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					r, err := options.fieldValue(reflect.ValueOf(p.Source), reflectedField)
+					if err != nil {
+						return nil, err
 					}
 
-					// Add limit filter
-					args["limit"] = &graphql.ArgumentConfig{
-						Type: graphql.Int,
+					// Fields promoted through a nil embedded pointer are null like nil pointers,
+					// so are empty omitempty fields
+					if !r.IsValid() || omitEmpty && isEmptyValue(r) {
+						return nil, nil
 					}
-				}
-			}
 
-			fields[strings.ToLower(structFieldName)] = &graphql.Field{
-				Name: structField.Name,
-				Type: structFieldType,
-				Args: args,
-				Resolve: func(p graphql.ResolveParams) (any, error) {
-					r := reflect.ValueOf(p.Source).FieldByName(structFieldName)
 					switch structFieldTypeKind {
 					case reflect.Func:
 						// Return 'null' if function field is nil
@@ -219,158 +566,964 @@ func createGraphQlFieldHierarchy(t reflect.Type, typesMap map[string]Pair[graphq
 							return nil, nil
 						}
 
-						// Function siganture is func() T
-						var err error
-						results := reflect.ValueOf(r.Interface()).Call([]reflect.Value{reflect.ValueOf(p.Source)})
-						if results[1].Interface() != nil {
-							err = results[1].Interface().(error)
-						}
-						return results[0].Interface(), err
-					case reflect.Slice, reflect.Array:
-
-						i := 0
-						j := r.Len()
-
-						// Evaluate the 'where' argument
-						filterOne, filterOneSet := p.Args["where"]
-						if filterOneSet {
-							for i := 0; i < j; i++ {
-								element := r.Index(i)
-
-								filter := filterOne.(map[string]interface{})
-								for fieldName, filterValue := range filter {
-
-									val := element.FieldByNameFunc(func(s string) bool {
-										return strings.ToLower(s) == fieldName
-									})
-
-									var match bool
-									// If the filter value is a number, then it is of type float64
-									// due to graphql.Float. See getBasicOutput.
-									switch filterValue.(type) {
-									case float64:
-										fv := filterValue.(float64)
-										rv := val.Interface()
-										if yFloat64, ok := rv.(float64); ok {
-											match = fv == yFloat64
-										} else if yInt, ok := rv.(int); ok {
-											match = fv == float64(yInt)
-										} else if yInt8, ok := rv.(int8); ok {
-											match = fv == float64(yInt8)
-										} else if yInt32, ok := rv.(int32); ok {
-											match = fv == float64(yInt32)
-										} else if yInt64, ok := rv.(int64); ok {
-											match = fv == float64(yInt64)
-										}
-									default:
-										match = filterValue == val.Interface()
-									}
-
-									if match {
-										return r.Slice(i, i+1).Interface(), nil
-									}
-								}
-
-							}
-							// item with path not found
-							return r.Slice(0, 0).Interface(), nil
+						r, err = signature.call(r, p)
+						if err != nil {
+							return nil, err
 						}
 
-						// Evaluate the 'skip' argument
-						skip, skipOk := p.Args["skip"]
-						if skipOk {
-							i = Min(skip.(int), j-1)
-						}
+						// The function consumes its own arguments, e.g. a 'limit'
+						// argument must not limit the list it returns as well
+						p.Args = omitArguments(p.Args, funcArgs)
+					}
 
-						// Evaluate the 'limit' argument
-						limit, limitOk := p.Args["limit"]
-						if limitOk {
-							j = Min(i+limit.(int), j)
+					if isSyncMap {
+						r, err = syncMapValue(r, syncMapType)
+						if err != nil {
+							return nil, err
 						}
+					}
 
-						return r.Slice(i, j).Interface(), nil
+					if tagged != nil {
+						return resolveTagged(r, tagged), nil
 					}
 
-					// remove r.Kind()?
-					switch r.Kind() {
-					case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-						// Use float since graphql int is limited to 32-bit.
-						// Check getBasicOutput() for more info.
-						return float64(r.Int()), nil
-
-					case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-						// Use float since graphql int is limited to 32-bit.
-						// Check getBasicOutput() for more info.
-						return float64(r.Uint()), nil
-
-					case reflect.Bool:
-						return r.Bool(), nil
-
-					case reflect.Float32, reflect.Float64:
-						return r.Float(), nil
-					case reflect.String:
-						return r.Interface(), nil
-					case reflect.Struct:
-
-						switch r.Type() {
-						case typeTime:
-							t := r.Interface().(time.Time).UnixMilli()
-							return float64(t), nil
-						}
+					if connection {
+						return connectList(r, p, options)
+					}
+					if paginated {
+						return paginateList(r, p, options)
+					}
+
+					return resolveFieldValue(r, p, structFieldName, options)
+				},
+			}
+
+			// Fields tagged with `graphql:",nonnull"` are non-null even if the Go type
+			// is nullable, e.g. pointers that are known to be always set.
+			if tag.has("nonnull") {
+				field.Type = graphql.NewNonNull(structFieldType)
+				field.Resolve = nonNullResolver(field.Resolve, structFieldName, typeName)
+			}
 
-						return r.Interface(), nil
+			fields[fieldName] = field
+		}
+
+		// Methods with a value receiver and no parameters are exposed as fields as well:
+		// func (d Dog) Relatives() []Dog
+		// func (d Dog) Relatives() ([]Dog, error)
+		for i := 0; i < t.NumMethod(); i++ {
+			method := t.Method(i)
+			if method.Type.NumIn() != 1 {
+				continue
+			}
+
+			numOut := method.Type.NumOut()
+			if numOut == 0 || numOut > 2 || (numOut == 2 && method.Type.Out(1) != typeError) {
+				continue
+			}
+
+			returnType := method.Type.Out(0)
+			if returnType.Kind() == reflect.Func || accessorMethods[method.Name] {
+				continue
+			}
+
+			// Skip the methods of e.g. fmt.Stringer, they aren't computed fields
+			if iface, ok := interfaceMethods[method.Name]; ok && t.Implements(iface) {
+				continue
+			}
+
+			methodFieldType, subfields, err := createGraphQlFieldHierarchy(returnType, appendPath(path, method.Name), typesMap, filterMap, options)
+			if err != nil {
+				return nil, nil, err
+			}
+			if methodFieldType == nil {
+				continue
+			}
+
+			// Methods are named like struct fields without tags, so e.g. an 'ID'
+			// field and an 'Id' method collide like two such fields would
+			methodName := method.Name
+			fieldName, _ := graphqlFieldName(reflect.StructField{Name: methodName})
+			if collidingField, ok := fields[fieldName]; ok {
+				switch options.nameCollisionPolicy {
+				case NameCollisionError:
+					return nil, nil, fmt.Errorf("field %s and method %s of %s both map to the GraphQL field %q", collidingField.Name, methodName, typeName, fieldName)
+				case NameCollisionFirstWins:
+					continue
+				case NameCollisionSuffix:
+					fieldName = suffixedFieldName(fields, fieldName)
+				}
+			}
+
+			args, err := createFieldArguments(methodName, returnType, subfields, filterMap, options)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			options.addReflectedField(typeName, fieldName, method.Type, methodField)
+			fields[fieldName] = &graphql.Field{
+				Name: methodName,
+				Type: methodFieldType,
+				Args: args,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					if err := canceled(p); err != nil {
+						return nil, err
 					}
 
-					return nil, errors.New("unknown type")
+					results := reflect.ValueOf(p.Source).MethodByName(methodName).Call(nil)
+					if len(results) == 2 && results[1].Interface() != nil {
+						return nil, results[1].Interface().(error)
+					}
+
+					return resolveFieldValue(results[0], p, methodName, options)
 				},
 			}
 		}
 
+		// Add a '<field>Count' sibling for every list field, so clients can count
+		// the elements without fetching them:
+		// dogs { name toysCount }
+		if options.countFieldsEnabled(t) {
+			for _, structField := range reflect.VisibleFields(t) {
+				kind := structField.Type.Kind()
+				if kind != reflect.Slice && kind != reflect.Array || isByteSequence(structField.Type) {
+					continue
+				}
+
+				// Skip list fields that are not part of the object,
+				// page objects come with their own 'total' field
+				listFieldName, ok := fieldNames[structField.Name]
+				if !ok || paginatedFields[structField.Name] {
+					continue
+				}
+				listField := fields[listFieldName]
+
+				countFieldName := listFieldName + "Count"
+				if _, ok := fields[countFieldName]; ok {
+					continue
+				}
+
+				// The count respects the same filter as the list field
+				args := graphql.FieldConfigArgument{}
+				if where, ok := listField.Args["where"]; ok {
+					args["where"] = where
+				}
+
+				reflectedField := structField
+				structFieldName := structField.Name
+				fields[countFieldName] = &graphql.Field{
+					Name: structFieldName + "Count",
+					Type: graphql.Int,
+					Args: args,
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						r, err := options.fieldValue(reflect.ValueOf(p.Source), reflectedField)
+						if err != nil {
+							return nil, err
+						}
+						list, err := resolveFieldValue(r, p, structFieldName, options)
+						if err != nil || list == nil {
+							return nil, err
+						}
+						return reflect.ValueOf(list).Len(), nil
+					},
+				}
+			}
+		}
+
+		// Registered object resolvers replace the source of all fields, see WithObjectResolver
+		if fn, ok := options.objectResolvers[t]; ok {
+			wrapObjectResolver(t, fields, fn)
+		}
+
+		// Elements of lists of pointers, e.g. '[]*Employee', and pointer roots are
+		// handed to the fields as pointers, also as pointers to pointers. The
+		// fields resolve from the struct value, or to null if a pointer is nil.
+		for _, field := range fields {
+			resolve := field.Resolve
+			field.Resolve = func(p graphql.ResolveParams) (any, error) {
+				if r := reflect.ValueOf(p.Source); r.Kind() == reflect.Pointer {
+					r, ok := indirectValue(r)
+					if !ok {
+						return nil, nil
+					}
+					p.Source = r.Interface()
+				}
+				return resolve(p)
+			}
+		}
+
+		return o, fields, nil
+	case reflect.Array, reflect.Slice:
+		// Byte sequences are encoded as base64 strings like encoding/json does,
+		// instead of a list of numbers.
+		if isByteSequence(t) {
+			return graphql.String, nil, nil
+		}
+
+		nt, fields, err := createGraphQlFieldHierarchy(t.Elem(), path, typesMap, filterMap, options)
+		if err != nil || nt == nil {
+			return nil, nil, err
+		}
+		return graphql.NewList(nt), fields, nil
+	case reflect.Map:
+		// Maps are exposed as a list of key/value objects:
+		// map[string]int{"a": 1} --> [{key: "a", value: 1}]
+		keyType := options.basicOutput(t.Key())
+		keyEnum, err := options.enum(t.Key())
+		if err != nil {
+			return nil, nil, err
+		}
+		if keyEnum != nil {
+			keyType = keyEnum.output
+		}
+		if keyType == nil {
+			return nil, nil, nil
+		}
+
+		// The value type is created first, so that the entry
+		// is named after the name of anonymous value structs
+		valueType, _, err := createGraphQlFieldHierarchy(t.Elem(), path, typesMap, filterMap, options)
+		if err != nil {
+			return nil, nil, err
+		}
+		if valueType == nil {
+			return nil, nil, nil
+		}
+
+		name := options.mapEntryTypeName(t)
+		knownType, ok := typesMap[name]
+		if ok {
+			return graphql.NewList(knownType.First), nil, nil
+		}
+
+		fields := graphql.Fields{
+			"key": &graphql.Field{
+				Name: "Key",
+				Type: keyType,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					key := p.Source.(mapEntry).Key
+					if keyEnum != nil {
+						return keyEnum.resolve(key)
+					}
+					return resolveValue(key, options)
+				},
+			},
+			"value": &graphql.Field{
+				Name: "Value",
+				Type: valueType,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					value, ok := indirectValue(p.Source.(mapEntry).Value)
+					if !ok {
+						return nil, nil
+					}
+					if enum, _ := options.enum(value.Type()); enum != nil {
+						return enum.resolve(value)
+					}
+					if _, ok := options.scalars[value.Type()]; ok {
+						return value.Interface(), nil
+					}
+					return resolveValue(value, options)
+				},
+			},
+		}
+
 		o := graphql.NewObject(graphql.ObjectConfig{
-			Name:   t.Name(),
+			Name:   name,
 			Fields: fields,
 		})
 
-		typesMap[t.Name()] = Pair[graphql.Output, graphql.Fields]{First: o, Second: fields}
+		typesMap[name] = Pair[graphql.Output, graphql.Fields]{First: o, Second: fields}
 
-		return o, fields
-	case reflect.Array, reflect.Slice:
-		nt, fields := createGraphQlFieldHierarchy(t.Elem(), typesMap, filterMap)
-		return graphql.NewList(nt), fields
+		// The entry fields are not returned as subfields, the
+		// map field gets its own 'key' argument instead.
+		return graphql.NewList(o), nil, nil
 	default:
-		return getBasicOutput(t), nil
+		return options.basicOutput(t), nil, nil
 	}
 }
 
-func QueryStructViaGraphql[T any](rootField string, o T, query string) ([]byte, error) {
-	typ, _ := createGraphQlFieldHierarchy(reflect.TypeOf(o), nil, nil)
+// Returns a copy of the path with the field name appended. The copy
+// ensures that sibling fields don't overwrite each other's paths.
+func appendPath(path []string, fieldName string) []string {
+	return append(path[:len(path):len(path)], fieldName)
+}
+
+// Wraps the resolver of a non-null field, so that resolving nil fails with
+// an error naming the field.
+func nonNullResolver(resolve graphql.FieldResolveFn, fieldName, typeName string) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		value, err := resolve(p)
+		if err == nil && value == nil {
+			return nil, fmt.Errorf("field %s of %s is tagged nonnull but resolved to nil", fieldName, typeName)
+		}
+		return value, err
+	}
+}
+
+// Appends the lowest free numeric suffix to a colliding field name,
+// starting with 2: 'id', 'id2', 'id3', ...
+func suffixedFieldName(fields graphql.Fields, fieldName string) string {
+	for i := 2; ; i++ {
+		name := fmt.Sprintf("%s%d", fieldName, i)
+		if _, ok := fields[name]; !ok {
+			return name
+		}
+	}
+}
+
+// The arguments of lists that keep the leading or trailing elements:
+// cats(where: {color: "Black"}, first: 3) { name }
+const (
+	firstArg = "first"
+	lastArg  = "last"
+)
+
+// Creates the arguments of a field whose resolved value is of type t,
+// e.g. the 'where', 'skip' and 'limit' filters of lists.
+func createFieldArguments(fieldName string, t reflect.Type, subfields graphql.Fields, filterMap map[string]graphql.ArgumentConfig, options *options) (graphql.FieldConfigArgument, error) {
+	args := graphql.FieldConfigArgument{}
+
+	// JSON values have no arguments, see jsonScalar
+	if isJSONType(t) {
+		return args, nil
+	}
+
+	// The fields of the elements of nested lists like [][]Cat are two lists deep
+	if isNestedList(t) {
+		subfields = nil
+	}
+
+	// Register all filter arguments
+	for k, v := range subfields {
+		// Arguments are optional, even for non-null fields
+		nullable := graphql.GetNullable(v.Type)
+		switch nullable {
+		case graphql.String, graphql.Int, graphql.Boolean, graphql.Float, int64Scalar, uint64Scalar:
+			args[k] = &graphql.ArgumentConfig{
+				Type: nullable.(graphql.Input),
+			}
+		}
+	}
+
+	switch t.Kind() {
+	// Add lookup parameter to maps
+	case reflect.Map:
+		key := options.basicOutput(t.Key())
+		enum, err := options.enum(t.Key())
+		if err != nil {
+			return nil, err
+		}
+		if enum != nil {
+			key = enum.output
+		}
+		args["key"] = &graphql.ArgumentConfig{
+			Type: key,
+		}
+
+		// Add where filter if the map contains structs or pointers
+		// to structs, it is applied to the values of the map.
+		if elem := indirectType(t.Elem()); elem.Kind() == reflect.Struct {
+			where, err := createFilterArgument(fieldName, elem, filterMap, options)
+			if err != nil {
+				return nil, err
+			}
+			args["where"] = where
+		}
+
+	// Add helper paramters to graphql lists
+	case reflect.Slice, reflect.Array:
+
+		// Byte sequences are strings, not lists
+		if isByteSequence(t) {
+			break
+		}
+
+		// Lists of time.Time can be filtered by a range, see timeRangeInput
+		if t.Elem() == typeTime {
+			args["where"] = &graphql.ArgumentConfig{
+				Type: timeRangeInput,
+			}
+		}
+
+		// Add where filter if the array or slice contains structs or pointers to structs
+		if elem := indirectType(t.Elem()); elem.Kind() == reflect.Struct && elem != typeTime {
+			where, err := createFilterArgument(fieldName, elem, filterMap, options)
+			if err != nil {
+				return nil, err
+			}
+			args["where"] = where
+			args[orderByArg] = &graphql.ArgumentConfig{
+				Type: orderByInput,
+			}
+			args[distinctArg] = &graphql.ArgumentConfig{
+				Type: graphql.String,
+			}
+		}
+
+		// Add first and last filters, renamed pagination arguments take precedence
+		args[firstArg] = &graphql.ArgumentConfig{
+			Type: graphql.Int,
+		}
+		args[lastArg] = &graphql.ArgumentConfig{
+			Type: graphql.Int,
+		}
+
+		// Add skip filter
+		args[options.skipArg] = &graphql.ArgumentConfig{
+			Type: graphql.Int,
+		}
+
+		// Add limit filter
+		args[options.limitArg] = &graphql.ArgumentConfig{
+			Type: graphql.Int,
+		}
+
+		if options.listSampling {
+			addSampleArguments(args)
+		}
+	}
+
+	return args, nil
+}
+
+// Returns the type that values of type t point to, following pointers to pointers.
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// Returns the value that r points to, following pointers to pointers,
+// or false if one of the pointers is nil or r is invalid.
+func indirectValue(r reflect.Value) (reflect.Value, bool) {
+	if !r.IsValid() {
+		return r, false
+	}
+	for r.Kind() == reflect.Pointer {
+		if r.IsNil() {
+			return r, false
+		}
+		r = r.Elem()
+	}
+	return r, true
+}
+
+// Resolves the value of a struct field, function or method
+// and applies the list and map arguments of the field.
+func resolveFieldValue(r reflect.Value, p graphql.ResolveParams, fieldName string, options *options) (any, error) {
+	// JSON values are serialized as they are, see jsonScalar
+	if t := indirectType(r.Type()); isJSONType(t) && options.unions[t] == nil {
+		if r, ok := indirectValue(r); ok {
+			return r.Interface(), nil
+		}
+		return nil, nil
+	}
+
+	// Interfaces are resolved by their dynamic value, see WithUnion
+	if r.Kind() == reflect.Interface {
+		if r.IsNil() {
+			return nil, nil
+		}
+		r = r.Elem()
+	}
+
+	// Nil pointers resolve to null, others to the value they point to
+	r, ok := indirectValue(r)
+	if !ok {
+		return nil, nil
+	}
+
+	if enum, _ := options.enum(r.Type()); enum != nil {
+		return enum.resolve(r)
+	}
+
+	// Registered scalars serialize the Go value themselves
+	if _, ok := options.scalars[r.Type()]; ok {
+		return r.Interface(), nil
+	}
+
+	switch r.Kind() {
+	case reflect.Slice, reflect.Array:
+
+		if isByteSequence(r.Type()) {
+			if r.Kind() == reflect.Slice && r.IsNil() {
+				return nil, nil
+			}
+			return base64.StdEncoding.EncodeToString(byteSequence(r)), nil
+		}
+
+		// See WithNilSlicesAsNull
+		if options.nilSlicesAsNull && r.Kind() == reflect.Slice && r.IsNil() {
+			return nil, nil
+		}
+
+		// Arrays are not addressable if they are part of a struct
+		// value, so they are copied to be sliced below.
+		if r.Kind() == reflect.Array && !r.CanAddr() {
+			array := reflect.New(r.Type()).Elem()
+			array.Set(r)
+			r = array
+		}
+
+		// Lists of time.Time keep all elements within the range of the
+		// 'where' argument and are then paginated like other lists
+		isTimeList := r.Type().Elem() == typeTime
+		if isTimeList {
+			timeRange, filtered := p.Args["where"].(map[string]any)
+			total := r.Len()
+			r = filterTimes(r, timeRange)
+			if stats := filterStatsFrom(p.Context); stats != nil && filtered {
+				stats.record(p, r.Len(), total)
+			}
+		}
+
+		// Evaluate the 'orderBy' argument before all others
+		if !isTimeList {
+			var err error
+			r, err = sortList(r, p, options)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// Evaluate the 'where' argument
+		if filter, ok := p.Args["where"].(map[string]any); ok && !isTimeList {
+			if err := checkFilterValues("where", r.Type().Elem(), filter, options); err != nil {
+				return nil, err
+			}
+			total := r.Len()
+			r = filterList(r, filter, options)
+			if stats := filterStatsFrom(p.Context); stats != nil {
+				stats.record(p, r.Len(), total)
+			}
+		}
+
+		// Evaluate the 'distinct' argument on the filtered and sorted elements
+		if !isTimeList {
+			var err error
+			r, err = distinctList(r, p, options)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		i := 0
+		j := r.Len()
+
+		// Evaluate the 'sample' argument, see WithListSampling
+		if n, ok := p.Args[sampleArg].(int); ok && options.listSampling {
+			var err error
+			r, err = sampleList(r, n, p.Args)
+			if err != nil {
+				return nil, err
+			}
+			j = r.Len()
+		}
+
+		// Evaluate the 'skip' argument, skipping all elements at most
+		if skip, ok := p.Args[options.skipArg].(int); ok {
+			i = max(0, Min(skip, j))
+		}
+
+		// Evaluate the 'limit' argument, negative limits select no elements
+		if limit, ok := p.Args[options.limitArg].(int); ok {
+			j = i + max(0, Min(limit, j-i))
+		}
+
+		// Evaluate the 'first' and 'last' arguments on the remaining elements,
+		// so 'last' applies to the elements kept by 'first'
+		if first, ok := p.Args[firstArg].(int); ok && firstArg != options.skipArg && firstArg != options.limitArg {
+			j = i + max(0, Min(first, j-i))
+		}
+		if last, ok := p.Args[lastArg].(int); ok && lastArg != options.skipArg && lastArg != options.limitArg {
+			i = j - max(0, Min(last, j-i))
+		}
+
+		// graphql-go serializes the elements of inner lists without a resolver,
+		// so they are resolved here, e.g. to encode inner byte sequences as base64.
+		// The arguments only apply to the outer list.
+		if isNestedList(r.Type()) {
+			inner := p
+			inner.Args = nil
+			elements := make([]any, 0, j-i)
+			for k := i; k < j; k++ {
+				element, err := resolveFieldValue(r.Index(k), inner, fieldName, options)
+				if err != nil {
+					return nil, err
+				}
+				elements = append(elements, element)
+			}
+			return elements, nil
+		}
+
+		// graphql-go serializes the elements without a resolver, so
+		// the values of enums have to be checked up front
+		if enum, _ := options.enum(r.Type().Elem()); enum != nil {
+			for k := i; k < j; k++ {
+				if _, err := enum.resolve(r.Index(k)); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if isTimeList {
+			return timestamps(r.Slice(i, j), options), nil
+		}
+
+		return r.Slice(i, j).Interface(), nil
+	case reflect.Map:
+		var entries []mapEntry
+
+		// Evaluate the 'key' argument
+		key, keySet := p.Args["key"]
+		if keySet {
+			entry, found := lookupMapEntry(r, key)
+			if !found {
+				if options.missingKeyPolicy == MissingKeyError {
+					return nil, fmt.Errorf("key %v not found in %s", key, fieldName)
+				}
+				return nil, nil
+			}
+			entries = []mapEntry{entry}
+		} else {
+			entries = mapEntries(r, options)
+		}
+
+		// Evaluate the 'where' argument against the values,
+		// all matching entries are kept.
+		filter, filterSet := p.Args["where"]
+		if filterSet {
+			if err := checkFilterValues("where", r.Type().Elem(), filter.(map[string]any), options); err != nil {
+				return nil, err
+			}
+			matches := make([]mapEntry, 0, len(entries))
+			for _, entry := range entries {
+				if matchesFilter(entry.Value, filter.(map[string]any), options) {
+					matches = append(matches, entry)
+				}
+			}
+			if stats := filterStatsFrom(p.Context); stats != nil {
+				stats.record(p, len(matches), len(entries))
+			}
+			entries = matches
+		}
+
+		return entries, nil
+	}
+
+	return resolveValue(r, options)
+}
+
+// Builds the schema for the given object and executes the query against it.
+func queryStruct[T any](rootField string, o T, query string, options *options) (*graphql.Result, error) {
+	schema, err := buildSchema(rootField, o, options)
+	if err != nil {
+		return nil, err
+	}
+	return querySchema(schema, query, options)
+}
+
+// Builds the schema that exposes the given object as the root field.
+func buildSchema[T any](rootField string, o T, options *options) (graphql.Schema, error) {
+	return buildRootSchema(rootField, reflect.TypeOf(o), func() (any, error) {
+		return o, nil
+	}, staticField, options)
+}
+
+// Builds the schema whose root field of type t resolves to the object
+// returned by load. kind tells whether the object can change between
+// queries, see isStaticQuery.
+func buildRootSchema(rootField string, t reflect.Type, load func() (any, error), kind fieldKind, options *options) (graphql.Schema, error) {
+	typesMap := map[string]Pair[graphql.Output, graphql.Fields]{}
+	filterMap := map[string]graphql.ArgumentConfig{}
+	typ, typeFields, err := createGraphQlFieldHierarchy(t, []string{rootField}, typesMap, filterMap, options)
+	if err != nil {
+		return graphql.Schema{}, err
+	}
+	if err := validateRootField(rootField, typeFields, options); err != nil {
+		return graphql.Schema{}, err
+	}
 	fields := graphql.Fields{}
-	fields[rootField] = &graphql.Field{
+	options.addReflectedField("RootQuery", rootField, t, kind)
+
+	// Root lists accept the same arguments as nested lists, e.g. dogs(where: {color: "Black"})
+	if elem := indirectType(t); (elem.Kind() == reflect.Slice || elem.Kind() == reflect.Array) && !isByteSequence(elem) {
+		fields[rootField], err = createRootListField(rootField, elem, load, typ, typesMap, filterMap, options)
+		if err != nil {
+			return graphql.Schema{}, err
+		}
+	} else {
+		fields[rootField] = &graphql.Field{
+			Type: typ,
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return load()
+			},
+		}
+	}
+
+	if options.serviceMetadata != nil {
+		if rootField == serviceFieldName {
+			return graphql.Schema{}, fmt.Errorf("root field %q collides with the service metadata field", rootField)
+		}
+
+		service, err := createServiceField(options.serviceMetadata, options)
+		if err != nil {
+			return graphql.Schema{}, err
+		}
+		fields[serviceFieldName] = service
+		options.addReflectedField("RootQuery", serviceFieldName, nil, funcField)
+	}
+
+	// @defer is declared for every schema, queries executed as a whole simply ignore it
+	directives := append([]*graphql.Directive{}, graphql.SpecifiedDirectives...)
+	directives = append(directives, deferDirective)
+
+	rootQuery := graphql.ObjectConfig{Name: "RootQuery", Fields: fields}
+	schemaConfig := graphql.SchemaConfig{Query: graphql.NewObject(rootQuery), Directives: directives}
+
+	// See MutationStructViaGraphql
+	if options.mutations {
+		o, err := load()
+		if err != nil {
+			return graphql.Schema{}, err
+		}
+		schemaConfig.Mutation, err = createMutationObject(reflect.ValueOf(o), typesMap, filterMap, options)
+		if err != nil {
+			return graphql.Schema{}, err
+		}
+	}
+
+	// See SubscribeStructViaGraphql
+	if options.subscriptions {
+		schemaConfig.Subscription, err = createSubscriptionObject(t, load, typesMap, filterMap, options)
+		if err != nil {
+			return graphql.Schema{}, err
+		}
+	}
+
+	schema, err := graphql.NewSchema(schemaConfig)
+	if err != nil {
+		return graphql.Schema{}, err
+	}
+
+	// See WithResolveHook
+	if options.resolveHook != nil {
+		hookResolvers(schema, options.resolveHook)
+	}
+	return schema, nil
+}
+
+// Creates the root field of a list of type t, which resolves the list
+// returned by load like a list field of a struct, including connections
+// and page objects.
+func createRootListField(rootField string, t reflect.Type, load func() (any, error), typ graphql.Output, typesMap map[string]Pair[graphql.Output, graphql.Fields], filterMap map[string]graphql.ArgumentConfig, options *options) (*graphql.Field, error) {
+	connection := options.connection(t, fieldTag{})
+	paginated := !connection && options.paginated(t, fieldTag{})
+	var args graphql.FieldConfigArgument
+	var err error
+	if connection {
+		typ = createConnectionObject(t, typ, typesMap, options)
+		args, err = createConnectionArguments(rootField, t, filterMap, options)
+	} else if paginated {
+		typ = createPageObject(t, typ, typesMap, options)
+		args, err = createPageArguments(rootField, t, filterMap, options)
+	} else {
+		// Root lists are filtered by 'where' only, they don't take the arguments
+		// that nested lists accept for each basic field of the elements
+		args, err = createFieldArguments(rootField, t, nil, filterMap, options)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &graphql.Field{
 		Type: typ,
+		Args: args,
 		Resolve: func(p graphql.ResolveParams) (any, error) {
-			return o, nil
+			o, err := load()
+			if err != nil {
+				return nil, err
+			}
+
+			r := reflect.ValueOf(o)
+			if connection {
+				return connectList(r, p, options)
+			}
+			if paginated {
+				return paginateList(r, p, options)
+			}
+			return resolveFieldValue(r, p, rootField, options)
 		},
+	}, nil
+}
+
+// Returns an error if the root field isn't a valid GraphQL name or uses the
+// '__' prefix reserved for introspection like '__schema' and '__type'. With
+// WithStrictRootField, a root field named like a field of its own type is
+// rejected as well.
+func validateRootField(rootField string, typeFields graphql.Fields, options *options) error {
+	if !graphqlName.MatchString(rootField) {
+		return fmt.Errorf("root field %q is not a valid GraphQL name", rootField)
+	}
+	if strings.HasPrefix(rootField, "__") {
+		return fmt.Errorf("root field %q uses the prefix '__' reserved for introspection", rootField)
 	}
+	if _, ok := typeFields[rootField]; ok && options.strictRootField {
+		return fmt.Errorf("root field %q collides with a field of its type", rootField)
+	}
+	return nil
+}
 
-	rootQuery := graphql.ObjectConfig{Name: "RootQuery", Fields: fields}
-	schemaConfig := graphql.SchemaConfig{Query: graphql.NewObject(rootQuery)}
-	schema, err := graphql.NewSchema(schemaConfig)
+// Executes the query against the schema and applies the result options.
+func querySchema(schema graphql.Schema, query string, options *options) (*graphql.Result, error) {
+	// See WithMaxQueryDepth
+	if err := checkQueryDepth(query, options); err != nil {
+		return nil, err
+	}
+
+	ctx := newQueryContext(options)
+	result, err := executeQuery(ctx, query, options.variables, schema)
 	if err != nil {
 		return nil, err
 	}
 
-	result, err := executeQuery(query, schema)
+	if stats := filterStatsFrom(ctx); stats != nil && len(stats.stats) > 0 {
+		if result.Extensions == nil {
+			result.Extensions = map[string]any{}
+		}
+		result.Extensions["filterStats"] = stats.stats
+	}
+
+	if options.deprecationWarnings {
+		deprecations, err := collectDeprecations(schema, query)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(deprecations) > 0 {
+			if result.Extensions == nil {
+				result.Extensions = map[string]any{}
+			}
+			result.Extensions["deprecations"] = deprecations
+		}
+	}
+
+	if options.orderedFields {
+		result.Data, err = orderResultData(query, result.Data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func QueryStructViaGraphql[T any](rootField string, o T, query string, opts ...Option) ([]byte, error) {
+	return QueryStructViaGraphqlContext(context.Background(), rootField, o, query, opts...)
+}
+
+// Executes the query like QueryStructViaGraphql within the given context,
+// e.g. the context of the HTTP request:
+//
+//	b, err := QueryStructViaGraphqlContext(c.Request().Context(), "dogs", dogs, post.Query)
+//
+// Function fields and methods receive it as their context.Context parameter,
+// e.g. to read request-scoped values like the authenticated user. Once the
+// context is canceled the query returns its error, and no more functions
+// or methods are called.
+func QueryStructViaGraphqlContext[T any](ctx context.Context, rootField string, o T, query string, opts ...Option) ([]byte, error) {
+	options := newOptions(opts)
+	options.ctx = ctx
+
+	return queryJSON(rootField, query, options, func() (graphql.Schema, error) {
+		return buildSchema(rootField, o, options)
+	})
+}
+
+// Executes the query against the schema returned by build and returns the
+// JSON of the result. The schema is only built if the result isn't cached.
+func queryJSON(rootField string, query string, options *options, build func() (graphql.Schema, error)) ([]byte, error) {
+	if options.explain {
+		schema, err := build()
+		if err != nil {
+			return nil, err
+		}
+		return explainQuery(schema, query, options)
+	}
+
+	cache := options.resultCache
+	var key string
+	if cache != nil {
+		var ok bool
+		if key, ok = cache.key(rootField, query, options.variables); ok {
+			if b, ok := cache.Store.Get(key); ok {
+				return b, nil
+			}
+		} else {
+			cache = nil
+		}
+	}
+
+	schema, err := build()
+	if err != nil {
+		return nil, err
+	}
+	result, err := querySchema(schema, query, options)
 	if err != nil {
 		return nil, err
 	}
 
-	b, err := json.MarshalIndent(result, "", "  ")
+	b, err := options.marshalResult(result)
 	if err != nil {
 		return nil, err
 	}
+
+	if cache != nil {
+		static, err := isStaticQuery(schema, query, options)
+		if err != nil {
+			return nil, err
+		}
+		if static {
+			cache.Store.Set(key, b, cache.TTL)
+		}
+	}
 	return b, nil
 }
 
+// Executes the query like QueryStructViaGraphql, but decodes the data of the
+// result into a value of type R instead of returning the JSON of the result,
+// e.g. to post-process the data in a handler:
+//
+//	type result struct {
+//		Dogs []struct{ Name string } `json:"dogs"`
+//	}
+//	r, err := QueryStructTyped[[]Dog, result]("dogs", dogs, "{ dogs { name } }")
+//
+// The data is decoded with encoding/json, so R uses the same json tags as the
+// response. The first error of the query is returned as error. The result
+// cache isn't used, since it holds marshaled results, and WithExplain isn't
+// supported, since the explanation isn't data of the query.
+func QueryStructTyped[T any, R any](rootField string, o T, query string, opts ...Option) (R, error) {
+	var r R
+	options := newOptions(opts)
+	if options.explain {
+		return r, errors.New("QueryStructTyped doesn't support WithExplain")
+	}
+
+	result, err := queryStruct(rootField, o, query, options)
+	if err != nil {
+		return r, err
+	}
+
+	b, err := json.Marshal(result.Data)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
+
 // Returns the minimum of the two given objects.
 func Min[T constraints.Integer](x, y T) T {
 	if x < y {
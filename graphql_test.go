@@ -0,0 +1,2677 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Compares the JSON result of a query with the expected JSON, ignoring
+// the formatting and the order of object keys. Numbers are compared by
+// their digits, so a large integer doesn't equal its float approximation.
+func assertJSON(t *testing.T, got []byte, want string) {
+	t.Helper()
+
+	gotValue, err := decodeJSON(got)
+	if err != nil {
+		t.Fatalf("invalid result %s: %v", got, err)
+	}
+	wantValue, err := decodeJSON([]byte(want))
+	if err != nil {
+		t.Fatalf("invalid expected result %s: %v", want, err)
+	}
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func decodeJSON(b []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+
+	var value any
+	err := decoder.Decode(&value)
+	return value, err
+}
+
+type testKennel struct {
+	Cats     []Cat
+	Arrivals <-chan Cat
+}
+
+func TestSubscribeStructViaGraphql(t *testing.T) {
+	arrivals := make(chan Cat, len(cats))
+	for _, cat := range cats {
+		arrivals <- cat
+	}
+	close(arrivals)
+
+	kennel := testKennel{Cats: cats, Arrivals: arrivals}
+	results, err := SubscribeStructViaGraphql(context.Background(), "kennel", kennel, `subscription { arrivals { name } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var received [][]byte
+	for b := range results {
+		received = append(received, b)
+	}
+	if len(received) != len(cats) {
+		t.Fatalf("got %d results, want %d", len(received), len(cats))
+	}
+	for i, cat := range cats {
+		assertJSON(t, received[i], `{"data": {"arrivals": {"name": "`+cat.Name+`"}}}`)
+	}
+
+	// Channels stay invisible to queries
+	b, err := QueryStructViaGraphql("kennel", kennel, `{ kennel { arrivals { name } } }`)
+	if err == nil {
+		t.Errorf("querying a channel field succeeded: %s", b)
+	}
+}
+
+func TestSubscribeStructViaGraphqlCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	kennel := testKennel{Arrivals: make(chan Cat)}
+	results, err := SubscribeStructViaGraphql(ctx, "kennel", kennel, `subscription { arrivals { name } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cancel()
+	for b := range results {
+		t.Errorf("unexpected result %s", b)
+	}
+}
+
+type testShelter struct {
+	Names map[int]string
+}
+
+var shelter = testShelter{Names: map[int]string{1: "Maru", 2: "Hana"}}
+
+type testRegistry struct {
+	DogsById map[string]*Dog
+}
+
+var registry = testRegistry{DogsById: map[string]*Dog{"c": &dogs[0], "a": &dogs[1], "b": &dogs[2]}}
+
+type testInventory struct {
+	Labels  map[string]string
+	Counts  map[string]int
+	Weights map[string]float64
+	Flags   map[string]bool
+}
+
+type testSnowflake struct {
+	ID       int64
+	Sequence uint64
+}
+
+var snowflakes = []testSnowflake{
+	{ID: 9007199254740993, Sequence: 18446744073709551615},
+	{ID: 9007199254740992, Sequence: 1},
+}
+
+type testPack struct {
+	Name   string
+	Leader func(self testPack) (*Cat, error)
+}
+
+type testBrokenPack struct {
+	Leader func(self testBrokenPack) (*Cat, int)
+}
+
+type testBlob struct {
+	Data     []byte
+	Checksum [4]byte
+}
+
+type testFile struct {
+	Name string
+	Raw  []byte
+	Text []byte `graphql:"bytesAsString"`
+}
+
+var files = []testFile{
+	{Name: "a.txt", Raw: []byte("hi"), Text: []byte("hello world")},
+	{Name: "b.txt", Raw: []byte{0xff}, Text: []byte("goodbye")},
+}
+
+type testPet interface{}
+
+type testOwner struct {
+	Name string
+	Pet  testPet
+}
+
+type testNode struct {
+	Name     string
+	Children []testNode
+	Parent   *testNode
+}
+
+type testCategory struct {
+	Name     string
+	Children []testCategory
+}
+
+type testEmail string
+
+type testContact struct {
+	Name  string
+	Email testEmail
+}
+
+var emailScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name: "Email",
+	Serialize: func(value any) any {
+		return strings.ToLower(string(value.(testEmail)))
+	},
+	ParseValue: func(value any) any {
+		if s, ok := value.(string); ok {
+			return testEmail(strings.ToLower(s))
+		}
+		return nil
+	},
+	ParseLiteral: func(valueAST ast.Value) any {
+		if s, ok := valueAST.(*ast.StringValue); ok {
+			return testEmail(strings.ToLower(s.Value))
+		}
+		return nil
+	},
+})
+
+type testAccount struct {
+	ID   int
+	Id   int
+	Name string
+}
+
+type testClowder struct {
+	Cats []*Cat
+}
+
+type testPodium struct {
+	Winners [3]Cat
+}
+
+type testProfile struct {
+	ID    int
+	First string
+	Last  string
+}
+
+func (p testProfile) DisplayName() string {
+	return p.First + " " + p.Last
+}
+
+func (p testProfile) Initials() (string, error) {
+	if p.First == "" || p.Last == "" {
+		return "", errors.New("incomplete name")
+	}
+	return p.First[:1] + p.Last[:1], nil
+}
+
+func (p testProfile) Id() string {
+	return fmt.Sprintf("profile-%d", p.ID)
+}
+
+type testHousehold struct {
+	Pets []*Dog
+	Cat  *Cat
+}
+
+type testSensor struct {
+	Name   string
+	Serial uint32
+	Port   uint16
+}
+
+type testParcel struct {
+	Label string  `graphql:"filterMaxLen=8"`
+	Size  int     `graphql:"filterMin=0,filterMax=10"`
+	Price float64 `graphql:"filterMin=0.5"`
+}
+
+var parcels = []testParcel{{Label: "small", Size: 2, Price: 3}, {Label: "large", Size: 9, Price: 12.5}}
+
+type testBrokenParcel struct {
+	Size int `graphql:"filterMaxLen=8"`
+}
+
+type testBreeder struct {
+	Litter func(self testBreeder) []Cat
+}
+
+type testShade string
+
+type testLamp struct {
+	Name    string
+	Shade   testShade
+	Spectra []testShade
+}
+
+const (
+	shadeWarm testShade = "warm"
+	shadeCold testShade = "cold"
+)
+
+var withShades = WithEnumValues(reflect.TypeOf(testShade("")), []any{"warm", "cold"})
+
+type testLeash struct {
+	Name  string
+	Owner *testOwner `graphql:",nonnull"`
+	Color *string    `graphql:",nonnull"`
+}
+
+type testPoint struct {
+	X, Y int
+}
+
+type testMarker struct {
+	Name string
+	At   testPoint
+}
+
+func parsePoint(s string) any {
+	var p testPoint
+	if _, err := fmt.Sscanf(s, "%d,%d", &p.X, &p.Y); err != nil {
+		return nil
+	}
+	return p
+}
+
+var pointScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name: "Point",
+	Serialize: func(value any) any {
+		p := value.(testPoint)
+		return fmt.Sprintf("%d,%d", p.X, p.Y)
+	},
+	ParseValue: func(value any) any {
+		if s, ok := value.(string); ok {
+			return parsePoint(s)
+		}
+		return nil
+	},
+	ParseLiteral: func(valueAST ast.Value) any {
+		if s, ok := valueAST.(*ast.StringValue); ok {
+			return parsePoint(s.Value)
+		}
+		return nil
+	},
+})
+
+type testSeries struct {
+	Timestamps []time.Time
+}
+
+type testRoster struct {
+	Names []string
+}
+
+type testAddArgs struct {
+	Name string
+	Age  int
+}
+
+func (a *testAddArgs) Validate() error {
+	if a.Name == "" {
+		return errors.New("name must not be empty")
+	}
+	if a.Age < 0 {
+		return fmt.Errorf("age must be >= 0, got %d", a.Age)
+	}
+	return nil
+}
+
+func (r *testRoster) Add(args testAddArgs) (int, error) {
+	r.Names = append(r.Names, args.Name)
+	return len(r.Names), nil
+}
+
+type testRoute struct {
+	Name    string
+	Pattern *regexp.Regexp
+	Exact   regexp.Regexp
+}
+
+type testSwitch struct {
+	Name    string
+	On      bool
+	Powered bool `graphql:"bool=int"`
+	Wired   bool `graphql:"bool=yesno"`
+}
+
+var switches = []testSwitch{
+	{Name: "hall", On: true, Powered: true, Wired: false},
+	{Name: "attic", On: false, Powered: false, Wired: true},
+}
+
+type testPalette struct {
+	Counts map[testShade]int
+}
+
+type testUser struct {
+	FullName string `json:"full_name,omitempty"`
+	Nick     string
+	Email    string `graphql:"mail" json:"email"`
+	Password string `json:"-"`
+	Login    string `graphql:"login" json:"-"`
+}
+
+var users = []testUser{
+	{FullName: "Ann Lee", Nick: "ann", Email: "ann@example.com", Password: "secret", Login: "alee"},
+	{FullName: "Ben Ito", Nick: "ben", Email: "ben@example.com", Password: "hunter2", Login: "bito"},
+}
+
+type testCredential struct {
+	User   string
+	Secret string `json:"-"`
+	Dash   string `json:"-,"`
+}
+
+type testPickArgs struct {
+	MinAge   int    `json:"min_age"`
+	Color    string `graphql:"coat" json:"color"`
+	Internal string `json:"-"`
+	Dash     string `json:"-,"`
+}
+
+type testKeeper struct {
+	Pick func(args testPickArgs) []Cat
+}
+
+type testCollar struct {
+	Name  string
+	Owner *Cat
+	Size  *int
+}
+
+type testAnimal struct {
+	Name string
+	Legs int
+}
+
+type testMammal struct {
+	testAnimal
+	Fur string
+}
+
+type testHound struct {
+	testMammal
+	Name  string `json:"name"`
+	Speed int
+}
+
+type testBird struct {
+	*testAnimal
+	Wings int
+}
+
+type testTagged struct {
+	Cat `json:"cat"`
+	Tag string
+}
+
+type testEvent struct {
+	Name string
+	At   time.Time
+	Ends *time.Time
+}
+
+type testCountArgs struct {
+	Count int
+}
+
+type testUserKey struct{}
+
+type testShop struct {
+	Name   string
+	Offers func(ctx context.Context, self testShop, args testCountArgs) ([]Cat, error)
+}
+
+type testWalker struct {
+	Name string
+	Dog  *Dog
+}
+
+type testRenameArgs struct {
+	From string
+	To   string
+}
+
+type testDogStore struct {
+	Dogs []Dog
+}
+
+func (s *testDogStore) Rename(args testRenameArgs) (Dog, error) {
+	for i := range s.Dogs {
+		if s.Dogs[i].Name == args.From {
+			s.Dogs[i].Name = args.To
+			return s.Dogs[i], nil
+		}
+	}
+	return Dog{}, fmt.Errorf("no dog named %s", args.From)
+}
+
+func (s testDogStore) Count() int {
+	return len(s.Dogs)
+}
+
+type testAge uint8
+
+type testWidths struct {
+	Name  string
+	I     int
+	I8    int8
+	I16   int16
+	I32   int32
+	I64   int64
+	U     uint
+	U8    uint8
+	U16   uint16
+	U32   uint32
+	U64   uint64
+	Named testAge
+}
+
+type testMember struct {
+	Handle  string
+	Display string `json:"display_name"`
+	Avatar  string `graphql:"picture"`
+	Region  string `graphql:"zone" json:"region_code"`
+	Token   string `graphql:"-" json:"token"`
+}
+
+type testBadge struct {
+	Label  string   `json:"label,omitempty"`
+	Level  int      `json:",omitempty"`
+	Active bool     `json:",omitempty"`
+	Tags   []string `json:",omitempty"`
+	Rank   int
+	Note   string
+}
+
+type testGrid struct {
+	Matrix [][]int
+	Groups [][]Cat
+	Fixed  [2][2]int
+}
+
+type testShelf struct {
+	Name string
+	Tags []string
+	Cats []Cat
+}
+
+type testOrder struct {
+	ID    int64   `json:"id,string"`
+	Total float64 `json:"total,string"`
+	Items int
+}
+
+type testTrickArgs struct {
+	Times int `graphql_desc:"How often the trick is shown"`
+}
+
+type testPuppy struct {
+	_     struct{} `graphql_desc:"A puppy of the kennel"`
+	Name  string   `graphql_desc:"The display name of the puppy"`
+	Age   int
+	Trick func(self testPuppy, args testTrickArgs) string `graphql_desc:"Shows a trick"`
+}
+
+type testPup struct {
+	Name  string
+	Greet func(self testPup) string
+	Best  func(self testPup) (*Cat, error)
+}
+
+type testMutePup struct {
+	Close func()
+}
+
+type testActive bool
+
+type testToggle struct {
+	Name    string
+	Enabled bool
+	Active  testActive
+}
+
+type testWalk struct {
+	Route  string
+	Length time.Duration
+	Splits []time.Duration
+	Rest   *time.Duration
+}
+
+type testCertificate struct {
+	Tag     xml.Name
+	Subject pkix.Name
+	Meta    struct {
+		Size int
+	}
+}
+
+type testWebhook struct {
+	Name    string
+	Payload json.RawMessage
+	Context any
+	Headers map[string]any
+}
+
+type testLitter struct {
+	Cats []Cat
+}
+
+func (l testLitter) Kittens() []Cat {
+	return l.Cats
+}
+
+func (l testLitter) String() string {
+	return "litter"
+}
+
+func TestQueries(t *testing.T) {
+	tests := []struct {
+		name  string
+		query func() ([]byte, error)
+		want  string
+	}{
+		{
+			name: "map key lookup",
+			query: func() ([]byte, error) {
+				return QueryStructViaGraphql("shelter", shelter, `{ shelter { names(key: 2) { key value } } }`)
+			},
+			want: `{"data": {"shelter": {"names": [{"key": 2, "value": "Hana"}]}}}`,
+		},
+		{
+			name: "map entries sorted by key",
+			query: func() ([]byte, error) {
+				inventory := testInventory{
+					Labels:  map[string]string{"b": "bowl", "a": "leash"},
+					Counts:  map[string]int{"b": 2, "a": 1},
+					Weights: map[string]float64{"a": 0.5},
+					Flags:   map[string]bool{"a": true, "b": false},
+				}
+				return QueryStructViaGraphql("inventory", inventory, `{ inventory { labels { key value } counts { key value } weights { key value } flags { key value } } }`)
+			},
+			want: `{"data": {"inventory": {
+				"labels": [{"key": "a", "value": "leash"}, {"key": "b", "value": "bowl"}],
+				"counts": [{"key": "a", "value": 1}, {"key": "b", "value": 2}],
+				"weights": [{"key": "a", "value": 0.5}],
+				"flags": [{"key": "a", "value": true}, {"key": "b", "value": false}]
+			}}}`,
+		},
+		{
+			name: "empty and nil maps",
+			query: func() ([]byte, error) {
+				inventory := testInventory{Labels: map[string]string{}}
+				return QueryStructViaGraphql("inventory", inventory, `{ inventory { labels { key } counts { key } } }`)
+			},
+			want: `{"data": {"inventory": {"labels": [], "counts": []}}}`,
+		},
+		{
+			name: "map filtered by the values",
+			query: func() ([]byte, error) {
+				return QueryStructViaGraphql("registry", registry, `{ registry { dogsbyid(where: {age_gt: 1}) { key value { name } } } }`)
+			},
+			want: `{"data": {"registry": {"dogsbyid": [{"key": "a", "value": {"name": "Momo"}}, {"key": "c", "value": {"name": "Bello"}}]}}}`,
+		},
+		{
+			name: "map key lookup combined with a filter",
+			query: func() ([]byte, error) {
+				return QueryStructViaGraphql("registry", registry, `{ registry { match: dogsbyid(key: "c", where: {age_gt: 1}) { key } miss: dogsbyid(key: "b", where: {age_gt: 1}) { key } } }`)
+			},
+			want: `{"data": {"registry": {"match": [{"key": "c"}], "miss": []}}}`,
+		},
+		{
+			name: "map key lookup of a truncated key",
+			query: func() ([]byte, error) {
+				return QueryStructViaGraphql("shelter", shelter, `{ shelter { names(key: 1.9) { key value } } }`)
+			},
+			want: `{"data": {"shelter": {"names": null}}}`,
+		},
+		{
+			name: "int64 beyond the precision of floats",
+			query: func() ([]byte, error) {
+				return QueryStructViaGraphql("snowflakes", snowflakes, `{ snowflakes(where: {id: 9007199254740993}) { id sequence } }`)
+			},
+			want: `{"data": {"snowflakes": [{"id": 9007199254740993, "sequence": 18446744073709551615}]}}`,
+		},
+		{
+			name: "int64 filter given as string",
+			query: func() ([]byte, error) {
+				return QueryStructViaGraphql("snowflakes", snowflakes, `{ snowflakes(where: {id: "9007199254740992"}) { id } }`)
+			},
+			want: `{"data": {"snowflakes": [{"id": 9007199254740992}]}}`,
+		},
+		{
+			name: "bytes as base64",
+			query: func() ([]byte, error) {
+				blobs := []testBlob{
+					{Data: []byte{0, 1, 254, 255}, Checksum: [4]byte{0xde, 0xad, 0xbe, 0xef}},
+					{},
+				}
+				return QueryStructViaGraphql("blobs", blobs, `{ blobs { data checksum } }`)
+			},
+			want: `{"data": {"blobs": [{"data": "AAH+/w==", "checksum": "3q2+7w=="}, {"data": null, "checksum": "AAAAAA=="}]}}`,
+		},
+		{
+			name: "bytes as base64 and as string",
+			query: func() ([]byte, error) {
+				return QueryStructViaGraphql("files", files, `{ files { raw text } }`)
+			},
+			want: `{"data": {"files": [{"raw": "aGk=", "text": "hello world"}, {"raw": "/w==", "text": "goodbye"}]}}`,
+		},
+		{
+			name: "bytes as string in where filters",
+			query: func() ([]byte, error) {
+				return QueryStructViaGraphql("files", files, `{ equal: files(where: {text: "goodbye"}) { name } contains: files(where: {text_contains: "world"}) { name } }`)
+			},
+			want: `{"data": {"equal": [{"name": "b.txt"}], "contains": [{"name": "a.txt"}]}}`,
+		},
+		{
+			name: "union members across elements",
+			query: func() ([]byte, error) {
+				owners := []testOwner{
+					{Name: "Ann", Pet: cats[0]},
+					{Name: "Ben", Pet: dogs[1]},
+					{Name: "Cid"},
+				}
+				return QueryStructViaGraphql("owners", owners, `{ owners { name pet { __typename ... on Cat { color } ... on Dog { age } } } }`,
+					WithUnion(reflect.TypeOf((*testPet)(nil)).Elem(), reflect.TypeOf(Cat{}), reflect.TypeOf(Dog{})))
+			},
+			want: `{"data": {"owners": [
+				{"name": "Ann", "pet": {"__typename": "Cat", "color": "White"}},
+				{"name": "Ben", "pet": {"__typename": "Dog", "age": 3}},
+				{"name": "Cid", "pet": null}
+			]}}`,
+		},
+		{
+			name: "tree recursive through a slice",
+			query: func() ([]byte, error) {
+				menu := testCategory{Name: "menu", Children: []testCategory{
+					{Name: "drinks", Children: []testCategory{
+						{Name: "tea", Children: []testCategory{{Name: "green"}}},
+						{Name: "coffee"},
+					}},
+				}}
+				return QueryStructViaGraphql("menu", menu, `{ menu { name children { name children(where: {name: "tea"}) { name children { name children { name } } } } } }`)
+			},
+			want: `{"data": {"menu": {"name": "menu", "children": [{"name": "drinks", "children": [
+				{"name": "tea", "children": [{"name": "green", "children": []}]}
+			]}]}}}`,
+		},
+		{
+			name: "self-referential type",
+			query: func() ([]byte, error) {
+				root := &testNode{Name: "root"}
+				leaf := testNode{Name: "leaf", Parent: &testNode{Name: "child", Parent: root}}
+				root.Children = []testNode{{Name: "child", Parent: root, Children: []testNode{leaf}}}
+				return QueryStructViaGraphql("tree", root, `{ tree { name children { name children { name parent { name parent { name } } } } } }`)
+			},
+			want: `{"data": {"tree": {"name": "root", "children": [{"name": "child", "children": [
+				{"name": "leaf", "parent": {"name": "child", "parent": {"name": "root"}}}
+			]}]}}}`,
+		},
+		{
+			name: "custom scalar",
+			query: func() ([]byte, error) {
+				contacts := []testContact{
+					{Name: "Ann", Email: "Ann@Example.com"},
+					{Name: "Ben", Email: "ben@example.com"},
+				}
+				return QueryStructViaGraphql("contacts", contacts, `{ contacts { name email } }`,
+					WithScalar(reflect.TypeOf(testEmail("")), emailScalar))
+			},
+			want: `{"data": {"contacts": [{"name": "Ann", "email": "ann@example.com"}, {"name": "Ben", "email": "ben@example.com"}]}}`,
+		},
+		{
+			name: "list of pointers with nil elements",
+			query: func() ([]byte, error) {
+				clowder := testClowder{Cats: []*Cat{&cats[0], nil, &cats[2]}}
+				return QueryStructViaGraphql("clowder", clowder, `{ clowder { cats { name } black: cats(where: {color: "Black"}) { name } } }`)
+			},
+			want: `{"data": {"clowder": {"cats": [{"name": "Maru"}, null, {"name": "Lily"}], "black": [{"name": "Lily"}]}}}`,
+		},
+		{
+			name: "array field",
+			query: func() ([]byte, error) {
+				podium := testPodium{Winners: [3]Cat(cats)}
+				return QueryStructViaGraphql("podium", podium, `{ podium { winners { name } top: winners(limit: 2) { name } black: winners(where: {color: "Black"}) { name } } }`)
+			},
+			want: `{"data": {"podium": {
+				"winners": [{"name": "Maru"}, {"name": "Hana"}, {"name": "Lily"}],
+				"top": [{"name": "Maru"}, {"name": "Hana"}],
+				"black": [{"name": "Lily"}]
+			}}}`,
+		},
+		{
+			name: "pointer sources of nested resolvers",
+			query: func() ([]byte, error) {
+				household := &testHousehold{Pets: []*Dog{&dogs[1]}, Cat: &cats[1]}
+				return QueryStructViaGraphql("household", &household, `{ household { pets { name friend { name } } cat { name } } }`)
+			},
+			want: `{"data": {"household": {"pets": [{"name": "Momo", "friend": {"name": "Maru"}}], "cat": {"name": "Hana"}}}}`,
+		},
+		{
+			name: "unsigned fields in where filters",
+			query: func() ([]byte, error) {
+				sensors := []testSensor{
+					{Name: "north", Serial: 4294967295, Port: 80},
+					{Name: "south", Serial: 7, Port: 443},
+				}
+				return QueryStructViaGraphql("sensors", sensors, `{ serial: sensors(where: {serial: 4294967295}) { name } port: sensors(where: {port_gt: 100}) { name } }`)
+			},
+			want: `{"data": {"serial": [{"name": "north"}], "port": [{"name": "south"}]}}`,
+		},
+		{
+			name: "filtered and paginated method list",
+			query: func() ([]byte, error) {
+				return QueryStructViaGraphql("litter", testLitter{Cats: cats}, `{ litter { kittens(where: {age_lt: 3}, skip: 1, limit: 1) { name } } }`)
+			},
+			want: `{"data": {"litter": {"kittens": [{"name": "Lily"}]}}}`,
+		},
+		{
+			name: "paginated list of pointers",
+			query: func() ([]byte, error) {
+				return QueryStructViaGraphql("cats", []*Cat{&cats[0], &cats[1], &cats[2]}, `{ cats(where: {age_lt: 3}, limit: 1) { items { name } total hasMore } }`, WithPaginatedLists())
+			},
+			want: `{"data": {"cats": {"items": [{"name": "Hana"}], "total": 2, "hasMore": true}}}`,
+		},
+		{
+			name: "count fields with and without a filter",
+			query: func() ([]byte, error) {
+				return QueryStructViaGraphql("litter", testLitter{Cats: cats}, `{ litter { catsCount young: catsCount(where: {age_lt: 3}) } }`, WithCountFields())
+			},
+			want: `{"data": {"litter": {"catsCount": 3, "young": 2}}}`,
+		},
+		{
+			name: "filtered root list",
+			query: func() ([]byte, error) {
+				return QueryStructViaGraphql("dogs", dogs, `{ dogs(where: {color: "Black"}, orderBy: {field: "name"}, limit: 1) { name } }`)
+			},
+			want: `{"data": {"dogs": [{"name": "Bello"}]}}`,
+		},
+		{
+			name: "root field named like a field of its type",
+			query: func() ([]byte, error) {
+				return QueryStructViaGraphql("name", cats, `{ name(where: {color: "Gray"}) { name } }`)
+			},
+			want: `{"data": {"name": [{"name": "Hana"}]}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := test.query()
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertJSON(t, b, test.want)
+		})
+	}
+}
+
+func TestFunctionFieldError(t *testing.T) {
+	pack := testPack{
+		Name: "Alley",
+		Leader: func(self testPack) (*Cat, error) {
+			return &cats[0], errors.New("no leader in " + self.Name)
+		},
+	}
+	schema, err := BuildSchema("pack", pack)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The error fails the field, the returned cat isn't resolved
+	result := graphql.Do(graphql.Params{Schema: schema, RequestString: `{ pack { name leader { name } } }`})
+	if len(result.Errors) != 1 || result.Errors[0].Message != "no leader in Alley" {
+		t.Errorf("got errors %v, want the error of the function", result.Errors)
+	}
+	b, err := json.Marshal(result.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"pack": {"name": "Alley", "leader": null}}`)
+}
+
+func TestFunctionFieldSignature(t *testing.T) {
+	_, err := BuildSchema("pack", testBrokenPack{})
+	if err == nil {
+		t.Error("building the schema of a function returning (*Cat, int) succeeded")
+	}
+}
+
+func TestInterfaceMethodsAreNotFields(t *testing.T) {
+	b, err := QueryStructViaGraphql("litter", testLitter{Cats: cats}, `{ litter { string } }`)
+	if err == nil {
+		t.Errorf("querying the String method succeeded: %s", b)
+	}
+}
+
+func TestRejectedRootFields(t *testing.T) {
+	tests := []struct {
+		rootField string
+		opts      []Option
+	}{
+		{rootField: "__schema"},
+		{rootField: "__cats"},
+		{rootField: "cat-list"},
+		{rootField: "name", opts: []Option{WithStrictRootField()}},
+	}
+
+	for _, test := range tests {
+		b, err := QueryStructViaGraphql(test.rootField, cats, `{ __typename }`, test.opts...)
+		if err == nil {
+			t.Errorf("root field %q was accepted: %s", test.rootField, b)
+		}
+	}
+}
+
+func TestRootListArguments(t *testing.T) {
+	// Root lists only take the arguments that are applied to the list
+	b, err := QueryStructViaGraphql("dogs", dogs, `{ dogs(color: "Black") { name } }`)
+	if err == nil {
+		t.Errorf("the no-op argument color was accepted: %s", b)
+	}
+}
+
+func TestNameCollisionPolicy(t *testing.T) {
+	accounts := []testAccount{{ID: 1, Id: 2, Name: "Ann"}}
+	_, err := QueryStructViaGraphql("accounts", accounts, `{ accounts { id } }`)
+	if err == nil || !strings.Contains(err.Error(), "ID") || !strings.Contains(err.Error(), "Id") {
+		t.Errorf("got error %v, want an error naming ID and Id", err)
+	}
+
+	b, err := QueryStructViaGraphql("accounts", accounts, `{ accounts { id id2 } }`, WithNameCollisionPolicy(NameCollisionSuffix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"accounts": [{"id": 1, "id2": 2}]}}`)
+}
+
+func TestResolveHook(t *testing.T) {
+	query := `{ cats(first: 2) { edges { node { name } } pageInfo { hasNextPage } } }`
+
+	// Every query builds a new schema, whose resolvers call only its own hook,
+	// so the calls of the hooks of earlier queries would add up here
+	calls := map[string]int{}
+	for i := 0; i < 3; i++ {
+		clear(calls)
+		hook := func(field string, duration time.Duration, err error) {
+			if duration < 0 {
+				t.Errorf("negative duration %v for %s", duration, field)
+			}
+			if err != nil {
+				t.Errorf("unexpected error %v for %s", err, field)
+			}
+			calls[field]++
+		}
+
+		_, err := QueryStructViaGraphql("cats", cats, query, WithConnections(), WithResolveHook(hook))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := map[string]int{
+			"RootQuery.cats":         1,
+			"CatConnection.edges":    1,
+			"CatEdge.node":           2,
+			"Cat.name":               2,
+			"CatConnection.pageInfo": 1,
+			"PageInfo.hasNextPage":   1,
+		}
+		if !reflect.DeepEqual(calls, want) {
+			t.Errorf("query %d: got calls %v, want %v", i+1, calls, want)
+		}
+	}
+}
+
+func TestMethodFields(t *testing.T) {
+	profiles := []testProfile{{ID: 1, First: "Ann", Last: "Lee"}, {ID: 2, First: "Ben"}}
+	query := `{ profiles { displayname initials } }`
+
+	// The ID field and the Id method collide
+	if _, err := QueryStructViaGraphql("profiles", profiles, query); err == nil {
+		t.Error("building the schema with the colliding ID field and Id method succeeded")
+	}
+
+	schema, err := BuildSchema("profiles", profiles, WithNameCollisionPolicy(NameCollisionSuffix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		query  string
+		want   string
+		errors []string
+	}{
+		{
+			name:  "without error",
+			query: `{ profiles { id id2 displayname } }`,
+			want:  `{"profiles": [{"id": 1, "id2": "profile-1", "displayname": "Ann Lee"}, {"id": 2, "id2": "profile-2", "displayname": "Ben "}]}`,
+		},
+		{
+			name:   "with error",
+			query:  query,
+			want:   `{"profiles": [{"displayname": "Ann Lee", "initials": "AL"}, {"displayname": "Ben ", "initials": null}]}`,
+			errors: []string{"incomplete name"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := graphql.Do(graphql.Params{Schema: schema, RequestString: test.query})
+			var errs []string
+			for _, err := range result.Errors {
+				errs = append(errs, err.Message)
+			}
+			if !reflect.DeepEqual(errs, test.errors) {
+				t.Errorf("got errors %v, want %v", errs, test.errors)
+			}
+
+			b, err := json.Marshal(result.Data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertJSON(t, b, test.want)
+		})
+	}
+}
+
+func TestQueryStructViaGraphqlNDJSON(t *testing.T) {
+	var b bytes.Buffer
+	err := QueryStructViaGraphqlNDJSON(&b, "cats", cats, `{ cats(where: {age_lt: 3}) { name } }`, WithOrderedFields())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\"name\":\"Hana\"}\n{\"name\":\"Lily\"}\n"
+	if b.String() != want {
+		t.Errorf("got %q, want %q", b.String(), want)
+	}
+
+	// Objects can't be split into lines
+	err = QueryStructViaGraphqlNDJSON(&b, "kennel", testKennel{Cats: cats}, `{ kennel { cats { name } } }`)
+	if err == nil {
+		t.Error("writing an object root field as NDJSON succeeded")
+	}
+}
+
+func TestFilterConstraints(t *testing.T) {
+	tests := []struct {
+		query     string
+		variables map[string]any
+		want      string
+		err       string
+	}{
+		{query: `{ parcels(where: {size: 9, label: "large"}) { label } }`, want: `{"data": {"parcels": [{"label": "large"}]}}`},
+		{query: `{ parcels(where: {size: 0, price: 0.5}) { label } }`, want: `{"data": {"parcels": []}}`},
+		{query: `{ parcels(where: {size: 11}) { label } }`, err: "where.size must be <= 10, got 11"},
+		{query: `{ parcels(where: {size: -1}) { label } }`, err: "where.size must be >= 0, got -1"},
+		{query: `{ parcels(where: {price: 0.25}) { label } }`, err: "where.price must be >= 0.5, got 0.25"},
+		{query: `{ parcels(where: {label: "very large"}) { label } }`, err: "where.label must be at most 8 characters long, got 10"},
+		{query: `{ parcels(where: {_or: [{size: 2}, {size: 12}]}) { label } }`, err: "where._or[1].size must be <= 10, got 12"},
+
+		// Operators aren't constrained
+		{query: `{ parcels(where: {size_lt: 11}) { label } }`, want: `{"data": {"parcels": [{"label": "small"}, {"label": "large"}]}}`},
+
+		// Variables have the type of the field
+		{
+			query:     `query($size: Float) { parcels(where: {size: $size}) { label } }`,
+			variables: map[string]any{"size": 2},
+			want:      `{"data": {"parcels": [{"label": "small"}]}}`,
+		},
+		{
+			query:     `query($size: Float) { parcels(where: {size: $size}) { label } }`,
+			variables: map[string]any{"size": 10.5},
+			err:       "where.size must be <= 10, got 10.5",
+		},
+	}
+
+	for _, test := range tests {
+		b, err := QueryStructViaGraphql("parcels", parcels, test.query, WithVariables(test.variables))
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: got error %v, want %q", test.query, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.query, err)
+			continue
+		}
+		assertJSON(t, b, test.want)
+	}
+
+	if _, err := BuildSchema("parcels", []testBrokenParcel{}); err == nil {
+		t.Error("building the schema with filterMaxLen on an int field succeeded")
+	}
+}
+
+func TestQueryStructViaGraphqlDeferred(t *testing.T) {
+	calls := 0
+	breeder := testBreeder{Litter: func(self testBreeder) []Cat {
+		calls++
+		return cats[:2]
+	}}
+
+	var b bytes.Buffer
+	err := QueryStructViaGraphqlDeferred(&b, "breeder", breeder, `{ breeder { litter { name age @defer color @defer(label: "coat") } } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var payloads []string
+	for _, part := range strings.Split(b.String(), "\r\n---\r\n")[1:] {
+		_, payload, _ := strings.Cut(part, "\r\n\r\n")
+		payload, _, _ = strings.Cut(payload, "\r\n-----\r\n")
+		payloads = append(payloads, payload)
+	}
+	want := []string{
+		`{"data": {"breeder": {"litter": [{"name": "Maru"}, {"name": "Hana"}]}}, "hasNext": true}`,
+		`{"data": {"age": 3}, "path": ["breeder", "litter", 0], "hasNext": true}`,
+		`{"data": {"age": 1}, "path": ["breeder", "litter", 1], "hasNext": true}`,
+		`{"data": {"color": "White"}, "path": ["breeder", "litter", 0], "label": "coat", "hasNext": true}`,
+		`{"data": {"color": "Gray"}, "path": ["breeder", "litter", 1], "label": "coat", "hasNext": false}`,
+	}
+	if len(payloads) != len(want) {
+		t.Fatalf("got payloads %q, want %d", payloads, len(want))
+	}
+	for i := range want {
+		assertJSON(t, []byte(payloads[i]), want[i])
+	}
+
+	// The func field leading to the deferred fields runs for every query
+	if calls != 3 {
+		t.Errorf("litter was called %d times, want once for the initial payload and once per deferred field", calls)
+	}
+}
+
+func TestEnumValues(t *testing.T) {
+	lamps := []testLamp{
+		{Name: "desk", Shade: "warm", Spectra: []testShade{"warm", "cold"}},
+		{Name: "porch", Shade: "cold"},
+	}
+	b, err := QueryStructViaGraphql("lamps", lamps, `{ lamps(where: {shade: cold}) { name shade } all: lamps { spectra } }`, withShades)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"lamps": [{"name": "porch", "shade": "cold"}], "all": [{"spectra": ["warm", "cold"]}, {"spectra": []}]}}`)
+
+	// Values outside the declared set fail instead of resolving to null
+	drifted := []testLamp{{Name: "attic", Shade: "neon"}}
+	_, err = QueryStructViaGraphql("lamps", drifted, `{ lamps { shade } }`, withShades)
+	if err == nil || err.Error() != "neon is not a value of enum testShade" {
+		t.Errorf("got error %v, want the out-of-set value to fail", err)
+	}
+	drifted = []testLamp{{Name: "attic", Shade: "warm", Spectra: []testShade{"warm", "uv"}}}
+	_, err = QueryStructViaGraphql("lamps", drifted, `{ lamps { spectra } }`, withShades)
+	if err == nil || !strings.Contains(err.Error(), "uv") {
+		t.Errorf("got error %v, want the out-of-set list element to fail", err)
+	}
+
+	// Filter values outside the set are rejected by the validation
+	if _, err := QueryStructViaGraphql("lamps", lamps, `{ lamps(where: {shade: neon}) { name } }`, withShades); err == nil {
+		t.Error("filtering by an undeclared enum value succeeded")
+	}
+}
+
+func TestPaginationArgs(t *testing.T) {
+	opt := WithPaginationArgs("offset", "count")
+	b, err := QueryStructViaGraphql("litter", testLitter{Cats: cats}, `{ litter { cats(offset: 1, count: 1) { name } kittens(offset: 2) { name } } }`, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"litter": {"cats": [{"name": "Hana"}], "kittens": [{"name": "Lily"}]}}}`)
+
+	b, err = QueryStructViaGraphql("cats", cats, `{ cats(count: 1, orderBy: {field: "age"}) { name } }`, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"cats": [{"name": "Hana"}]}}`)
+
+	// The default names are replaced, not added to
+	if b, err := QueryStructViaGraphql("cats", cats, `{ cats(skip: 1) { name } }`, opt); err == nil {
+		t.Errorf("the skip argument was accepted: %s", b)
+	}
+}
+
+func TestNonNullPointers(t *testing.T) {
+	red := "red"
+	leashes := []testLeash{{Name: "long", Owner: &testOwner{Name: "Ann"}, Color: &red}}
+	b, err := QueryStructViaGraphql("leashes", leashes, `{ leashes { owner { name } color } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"leashes": [{"owner": {"name": "Ann"}, "color": "red"}]}}`)
+
+	sdl, err := SchemaSDL("leashes", leashes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The owner field takes the subfields of testOwner as arguments
+	for _, field := range []string{"owner(name: String): testOwner!", "color: String!"} {
+		if !strings.Contains(sdl, field) {
+			t.Errorf("SDL doesn't contain %q:\n%s", field, sdl)
+		}
+	}
+
+	tests := []struct {
+		leash testLeash
+		query string
+		err   string
+	}{
+		{testLeash{Name: "short", Color: &red}, `{ leashes { owner { name } } }`, "field Owner of testLeash is tagged nonnull but resolved to nil"},
+		{testLeash{Name: "short", Owner: &testOwner{}}, `{ leashes { color } }`, "field Color of testLeash is tagged nonnull but resolved to nil"},
+	}
+	for _, test := range tests {
+		_, err := QueryStructViaGraphql("leashes", []testLeash{test.leash}, test.query)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%s: got error %v, want %q", test.query, err, test.err)
+		}
+	}
+}
+
+func TestRegexFilter(t *testing.T) {
+	tests := []struct {
+		query     string
+		variables map[string]any
+		want      string
+		err       string
+	}{
+		{query: `{ cats(where: {name_regex: "^(Ma|Li)"}) { name } }`, want: `{"data": {"cats": [{"name": "Maru"}, {"name": "Lily"}]}}`},
+		{
+			query:     `query($re: Regex) { cats(where: {_or: [{name_regex: $re}]}) { name } }`,
+			variables: map[string]any{"re": "a$"},
+			want:      `{"data": {"cats": [{"name": "Hana"}]}}`,
+		},
+		{query: `{ cats(where: {name_regex: "(("}) { name } }`, err: "where.name_regex: error parsing regexp: missing closing ): `((`"},
+		{
+			query:     `query($re: Regex) { cats(where: {_or: [{name_regex: $re}]}) { name } }`,
+			variables: map[string]any{"re": "[a"},
+			err:       "where._or[0].name_regex: error parsing regexp: missing closing ]: `[a`",
+		},
+		{
+			query: `{ cats(where: {name_regex: "` + strings.Repeat("a", maxRegexLength+1) + `"}) { name } }`,
+			err:   "where.name_regex: regular expression of 257 characters is longer than the maximum of 256",
+		},
+	}
+
+	for _, test := range tests {
+		b, err := QueryStructViaGraphql("cats", cats, test.query, WithVariables(test.variables))
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%.60s: got error %v, want %q", test.query, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.query, err)
+			continue
+		}
+		assertJSON(t, b, test.want)
+	}
+}
+
+func TestStructScalar(t *testing.T) {
+	markers := []testMarker{{Name: "gate", At: testPoint{3, 4}}, {Name: "well", At: testPoint{0, 1}}}
+	withPoint := WithScalar(reflect.TypeOf(testPoint{}), pointScalar)
+
+	b, err := QueryStructViaGraphql("markers", markers, `query($at: Point) { markers { name at } found: markers(where: {at: "3,4"}) { name } variable: markers(where: {at: $at}) { name } }`,
+		withPoint, WithVariables(map[string]any{"at": "0,1"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {
+		"markers": [{"name": "gate", "at": "3,4"}, {"name": "well", "at": "0,1"}],
+		"found": [{"name": "gate"}],
+		"variable": [{"name": "well"}]
+	}}`)
+
+	// The struct isn't exposed as an object
+	sdl, err := SchemaSDL("markers", markers, withPoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sdl, "scalar Point") || strings.Contains(sdl, "type testPoint") {
+		t.Errorf("SDL doesn't expose testPoint as the Point scalar:\n%s", sdl)
+	}
+}
+
+func TestTimeRangeFilter(t *testing.T) {
+	series := testSeries{Timestamps: []time.Time{
+		time.Date(2023, 12, 31, 23, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}}
+
+	// 1735689600000 is 2025-01-01T00:00:00Z in Unix milliseconds
+	b, err := QueryStructViaGraphql("series", series, `{ series {
+		range: timestamps(where: {gte: "2024-01-01T00:00:00Z", lt: 1735689600000})
+		after: timestamps(where: {gt: "2024-01-01T00:00:00Z"}, limit: 1)
+		upTo: timestamps(where: {lte: "2024-01-01T00:00:00Z"}, skip: 1)
+	} }`, WithTimeFormat(TimeRFC3339))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"series": {
+		"range": ["2024-01-01T00:00:00Z", "2024-06-01T00:00:00Z"],
+		"after": ["2024-06-01T00:00:00Z"],
+		"upTo": ["2024-01-01T00:00:00Z"]
+	}}}`)
+}
+
+func TestArgumentValidation(t *testing.T) {
+	roster := testRoster{}
+	b, err := MutationStructViaGraphql("roster", &roster, `mutation { add(name: "Maru", age: 3) }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"add": 1}}`)
+
+	tests := []struct {
+		mutation string
+		err      string
+	}{
+		{`mutation { add(age: 3) }`, "name must not be empty"},
+		{`mutation { add(name: "Hana", age: -1) }`, "age must be >= 0, got -1"},
+	}
+	for _, test := range tests {
+		_, err := MutationStructViaGraphql("roster", &roster, test.mutation)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%s: got error %v, want %q", test.mutation, err, test.err)
+		}
+	}
+
+	// The method isn't called for invalid arguments
+	if !reflect.DeepEqual(roster.Names, []string{"Maru"}) {
+		t.Errorf("got names %v, want only the valid one added", roster.Names)
+	}
+}
+
+func TestRegexFields(t *testing.T) {
+	routes := []testRoute{
+		{Name: "users", Pattern: regexp.MustCompile(`^/users/(\d+)$`), Exact: *regexp.MustCompile(`^/users$`)},
+		{Name: "fallback"},
+	}
+	b, err := QueryStructViaGraphql("routes", routes, `{ routes { name pattern exact } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"routes": [
+		{"name": "users", "pattern": "^/users/(\\d+)$", "exact": "^/users$"},
+		{"name": "fallback", "pattern": null, "exact": ""}
+	]}}`)
+}
+
+func TestBoolRepresentations(t *testing.T) {
+	tests := []struct {
+		query     string
+		variables map[string]any
+		want      string
+	}{
+		{
+			query: `{ switches { name on powered wired } }`,
+			want:  `{"data": {"switches": [{"name": "hall", "on": true, "powered": 1, "wired": "no"}, {"name": "attic", "on": false, "powered": 0, "wired": "yes"}]}}`,
+		},
+		{
+			query: `{ on: switches(where: {on: true}) { name } powered: switches(where: {powered: 0}) { name } wired: switches(where: {wired: "no"}) { name } }`,
+			want:  `{"data": {"on": [{"name": "hall"}], "powered": [{"name": "attic"}], "wired": [{"name": "hall"}]}}`,
+		},
+		{
+			// Variables decoded from JSON hold float64 numbers
+			query:     `query($p: BoolInt, $w: BoolYesNo) { switches(where: {powered: $p, wired: $w}) { name } }`,
+			variables: map[string]any{"p": 0.0, "w": "yes"},
+			want:      `{"data": {"switches": [{"name": "attic"}]}}`,
+		},
+	}
+	for _, test := range tests {
+		b, err := QueryStructViaGraphql("switches", switches, test.query, WithVariables(test.variables))
+		if err != nil {
+			t.Errorf("%s: %v", test.query, err)
+			continue
+		}
+		assertJSON(t, b, test.want)
+	}
+
+	for _, query := range []string{
+		`{ switches(where: {powered: 2}) { name } }`,
+		`{ switches(where: {powered: true}) { name } }`,
+		`{ switches(where: {wired: "maybe"}) { name } }`,
+	} {
+		if b, err := QueryStructViaGraphql("switches", switches, query); err == nil {
+			t.Errorf("%s: the invalid representation was accepted: %s", query, b)
+		}
+	}
+}
+
+func TestEnumKeyedMap(t *testing.T) {
+	palette := testPalette{Counts: map[testShade]int{"cold": 2, "warm": 5}}
+
+	// Sorted by the declared order of the values, not alphabetically
+	b, err := QueryStructViaGraphql("palette", palette, `{ palette { counts { key value } cold: counts(key: cold) { value } } }`, withShades)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"palette": {"counts": [{"key": "warm", "value": 5}, {"key": "cold", "value": 2}], "cold": [{"value": 2}]}}}`)
+
+	if b, err := QueryStructViaGraphql("palette", palette, `{ palette { counts(key: neon) { value } } }`, withShades); err == nil {
+		t.Errorf("looking up an undeclared enum key succeeded: %s", b)
+	}
+}
+
+func TestJSONFieldNames(t *testing.T) {
+	// Named by the json tag, the lowercased Go name or the graphql tag, which takes precedence
+	b, err := QueryStructViaGraphql("users", users, `{ users(where: {full_name: "Ben Ito", nick: "ben", mail: "ben@example.com", login: "bito"}) { full_name nick mail login } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"users": [{"full_name": "Ben Ito", "nick": "ben", "mail": "ben@example.com", "login": "bito"}]}}`)
+
+	for _, query := range []string{
+		`{ users { fullname } }`,
+		`{ users { email } }`,
+		`{ users { password } }`,
+		`{ users(where: {password: "secret"}) { nick } }`,
+		`{ users(orderBy: {field: "password"}) { nick } }`,
+	} {
+		if b, err := QueryStructViaGraphql("users", users, query); err == nil {
+			t.Errorf("%s: the field was exposed: %s", query, b)
+		}
+	}
+}
+
+func TestHiddenFields(t *testing.T) {
+	var logged bytes.Buffer
+	credentials := []testCredential{{User: "ann", Secret: "secret", Dash: "dash"}}
+	schema, err := BuildSchema("credentials", credentials, WithLogger(log.New(&logged, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	object := schema.QueryType().Fields()["credentials"].Type.(*graphql.List).OfType.(*graphql.Object)
+	var names []string
+	for name := range object.Fields() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if !reflect.DeepEqual(names, []string{"user"}) {
+		t.Errorf("got fields %v, want only user", names)
+	}
+	if !strings.Contains(logged.String(), "skipping field Dash of testCredential") {
+		t.Errorf("the skipped field Dash wasn't logged: %q", logged.String())
+	}
+
+	// Arguments are named and hidden like fields
+	keeper := testKeeper{Pick: func(args testPickArgs) []Cat {
+		var picked []Cat
+		for _, cat := range cats {
+			if cat.Age >= args.MinAge && (args.Color == "" || cat.Color == args.Color) {
+				picked = append(picked, cat)
+			}
+		}
+		return picked
+	}}
+	b, err := QueryStructViaGraphql("keeper", keeper, `{ keeper { pick(min_age: 2, coat: "White") { name } } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"keeper": {"pick": [{"name": "Maru"}]}}}`)
+
+	for _, query := range []string{
+		`{ keeper { pick(minage: 2) { name } } }`,
+		`{ keeper { pick(internal: "x") { name } } }`,
+	} {
+		if b, err := QueryStructViaGraphql("keeper", keeper, query); err == nil {
+			t.Errorf("%s: the argument was accepted: %s", query, b)
+		}
+	}
+}
+
+func TestPointerFields(t *testing.T) {
+	size := 30
+	collars := []testCollar{{Name: "red", Owner: &cats[0], Size: &size}, {Name: "blue"}}
+	b, err := QueryStructViaGraphql("collars", collars, `{ collars { name owner { name age } size } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"collars": [{"name": "red", "owner": {"name": "Maru", "age": 3}, "size": 30}, {"name": "blue", "owner": null, "size": null}]}}`)
+
+	// The pointers are exposed like the types they point to
+	sdl, err := SchemaSDL("collars", collars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"owner(age: Float, color: String, name: String): Cat\n", "size: Float\n"} {
+		if !strings.Contains(sdl, field) {
+			t.Errorf("SDL doesn't contain %q:\n%s", field, sdl)
+		}
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	b, err := QueryStructViaGraphql("cats", cats, `{
+		ageAsc: cats(orderBy: {field: "age"}) { name }
+		ageDesc: cats(orderBy: {field: "age", direction: DESC}) { name }
+		nameAsc: cats(orderBy: {field: "name", direction: ASC}) { name }
+		nameDesc: cats(orderBy: {field: "name", direction: DESC}, limit: 2) { name }
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {
+		"ageAsc": [{"name": "Hana"}, {"name": "Lily"}, {"name": "Maru"}],
+		"ageDesc": [{"name": "Maru"}, {"name": "Lily"}, {"name": "Hana"}],
+		"nameAsc": [{"name": "Hana"}, {"name": "Lily"}, {"name": "Maru"}],
+		"nameDesc": [{"name": "Maru"}, {"name": "Lily"}]
+	}}`)
+
+	_, err = QueryStructViaGraphql("cats", cats, `{ cats(orderBy: {field: "weight"}) { name } }`)
+	if err == nil || !strings.Contains(err.Error(), "weight") {
+		t.Errorf("got error %v, want an error naming the missing field", err)
+	}
+}
+
+func TestFilterCombinations(t *testing.T) {
+	tests := []struct {
+		where string
+		want  string
+	}{
+		// All fields have to match
+		{`{color: "Black", age: 2}`, `[{"name": "Lily"}]`},
+		{`{color: "Black", age: 3}`, `[]`},
+		{`{age_gt: 1, name_ne: "Lily"}`, `[{"name": "Maru"}]`},
+		{`{age_gte: 1}`, `[{"name": "Maru"}, {"name": "Hana"}, {"name": "Lily"}]`},
+
+		// At least one alternative has to match
+		{`{_or: [{color: "Purple"}, {age: 9}]}`, `[]`},
+		{`{_or: [{color: "Gray"}, {age: 9}]}`, `[{"name": "Hana"}]`},
+		{`{_or: [{color: "Gray"}, {age: 3}]}`, `[{"name": "Maru"}, {"name": "Hana"}]`},
+		{`{_or: []}`, `[{"name": "Maru"}, {"name": "Hana"}, {"name": "Lily"}]`},
+
+		// Combined with the other fields
+		{`{age_lt: 3, _or: [{color: "Gray"}, {color: "Black"}]}`, `[{"name": "Hana"}, {"name": "Lily"}]`},
+		{`{age_lt: 2, _or: [{color: "White"}, {color: "Black"}]}`, `[]`},
+	}
+
+	for _, test := range tests {
+		b, err := QueryStructViaGraphql("cats", cats, `{ cats(where: `+test.where+`) { name } }`)
+		if err != nil {
+			t.Errorf("%s: %v", test.where, err)
+			continue
+		}
+		assertJSON(t, b, `{"data": {"cats": `+test.want+`}}`)
+	}
+}
+
+func TestEmbeddedStructs(t *testing.T) {
+	tests := []struct {
+		name  string
+		query func() ([]byte, error)
+		want  string
+	}{
+		{
+			name: "single",
+			query: func() ([]byte, error) {
+				mammals := []testMammal{{testAnimal: testAnimal{Name: "cat", Legs: 4}, Fur: "short"}}
+				return QueryStructViaGraphql("mammals", mammals, `{ mammals(where: {legs: 4}) { name legs fur } }`)
+			},
+			want: `{"data": {"mammals": [{"name": "cat", "legs": 4, "fur": "short"}]}}`,
+		},
+		{
+			name: "double and shadowed",
+			query: func() ([]byte, error) {
+				hounds := []testHound{{testMammal: testMammal{testAnimal: testAnimal{Name: "animal", Legs: 4}, Fur: "long"}, Name: "hound", Speed: 9}}
+				return QueryStructViaGraphql("hounds", hounds, `{ hounds(where: {name: "hound"}) { name legs fur speed } }`)
+			},
+			want: `{"data": {"hounds": [{"name": "hound", "legs": 4, "fur": "long", "speed": 9}]}}`,
+		},
+		{
+			name: "nil embedded pointer",
+			query: func() ([]byte, error) {
+				birds := []testBird{{testAnimal: &testAnimal{Name: "crow", Legs: 2}, Wings: 2}, {Wings: 2}}
+				return QueryStructViaGraphql("birds", birds, `{ birds { name legs wings } }`)
+			},
+			want: `{"data": {"birds": [{"name": "crow", "legs": 2, "wings": 2}, {"name": null, "legs": null, "wings": 2}]}}`,
+		},
+		{
+			name: "named by a tag",
+			query: func() ([]byte, error) {
+				tagged := testTagged{Cat: cats[0], Tag: "t"}
+				return QueryStructViaGraphql("tagged", tagged, `{ tagged { tag cat { name } } }`)
+			},
+			want: `{"data": {"tagged": {"tag": "t", "cat": {"name": "Maru"}}}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := test.query()
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertJSON(t, b, test.want)
+		})
+	}
+
+	// The shadowed field isn't exposed twice
+	if b, err := QueryStructViaGraphql("hounds", []testHound{}, `{ hounds { testmammal { name } } }`); err == nil {
+		t.Errorf("the embedded struct was exposed as a field: %s", b)
+	}
+}
+
+func TestConnectionPaging(t *testing.T) {
+	type page struct {
+		Cats struct {
+			Edges []struct {
+				Node   struct{ Name string }
+				Cursor string
+			}
+			PageInfo struct {
+				HasNextPage bool
+				EndCursor   *string
+			}
+		}
+	}
+
+	// Pages through the cats two at a time, following the end cursors
+	var names []string
+	var hasNext []bool
+	after := ""
+	for i := 0; i < 3; i++ {
+		query := `query($after: String) { cats(first: 2, after: $after) { edges { node { name } cursor } pageInfo { hasNextPage endCursor } } }`
+		variables := map[string]any{}
+		if after != "" {
+			variables["after"] = after
+		}
+		b, err := QueryStructViaGraphql("cats", cats, query, WithConnections(), WithVariables(variables))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result page
+		if err := json.Unmarshal(b, &struct{ Data *page }{&result}); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, edge := range result.Cats.Edges {
+			names = append(names, edge.Node.Name)
+		}
+		hasNext = append(hasNext, result.Cats.PageInfo.HasNextPage)
+		if !result.Cats.PageInfo.HasNextPage {
+			break
+		}
+		after = *result.Cats.PageInfo.EndCursor
+	}
+
+	if !reflect.DeepEqual(names, []string{"Maru", "Hana", "Lily"}) {
+		t.Errorf("got names %v, want all cats in order", names)
+	}
+	if !reflect.DeepEqual(hasNext, []bool{true, false}) {
+		t.Errorf("got hasNextPage %v, want true for the first and false for the last page", hasNext)
+	}
+}
+
+func TestQueryStructTyped(t *testing.T) {
+	type result struct {
+		Dogs []Dog `json:"dogs"`
+	}
+	r, err := QueryStructTyped[[]Dog, result]("dogs", dogs, `{ dogs(where: {color: "White"}) { name age color friend { name } } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Dog{{Name: "Momo", Age: 3, Color: "White", Friend: Cat{Name: "Maru"}}}
+	if !reflect.DeepEqual(r.Dogs, want) {
+		t.Errorf("got dogs %+v, want %+v", r.Dogs, want)
+	}
+
+	if _, err := QueryStructTyped[[]Dog, result]("dogs", dogs, `{ dogs { weight } }`); err == nil {
+		t.Error("querying an unknown field succeeded")
+	}
+	if _, err := QueryStructTyped[[]Dog, result]("dogs", dogs, `{ dogs { name } }`, WithExplain()); err == nil {
+		t.Error("querying with WithExplain succeeded")
+	}
+}
+
+func TestVariables(t *testing.T) {
+	query := `query($color: String, $age: Float, $n: Int = 1, $where: cats) {
+		byColor: cats(where: {color: $color}) { name }
+		older: cats(where: {age_gte: $age}, limit: $n) { name }
+		filter: cats(where: $where) { name }
+	}`
+	tests := []struct {
+		variables map[string]any
+		want      string
+	}{
+		{
+			variables: map[string]any{"color": "Black", "age": 2, "n": 2, "where": map[string]any{"name_startsWith": "H"}},
+			want:      `{"data": {"byColor": [{"name": "Lily"}], "older": [{"name": "Maru"}, {"name": "Lily"}], "filter": [{"name": "Hana"}]}}`,
+		},
+		{
+			// Missing variables are null or take their default value, like numbers decoded from JSON
+			variables: map[string]any{"color": "Gray", "age": 1.0},
+			want:      `{"data": {"byColor": [{"name": "Hana"}], "older": [{"name": "Maru"}], "filter": [{"name": "Maru"}, {"name": "Hana"}, {"name": "Lily"}]}}`,
+		},
+	}
+	for _, test := range tests {
+		b, err := QueryStructViaGraphql("cats", cats, query, WithVariables(test.variables))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertJSON(t, b, test.want)
+	}
+
+	if b, err := QueryStructViaGraphql("cats", cats, `query($n: Int) { cats(limit: $n) { name } }`, WithVariables(map[string]any{"n": "two"})); err == nil {
+		t.Errorf("a variable of the wrong type was accepted: %s", b)
+	}
+}
+
+func TestTimeFormats(t *testing.T) {
+	at := time.Date(2024, 1, 31, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	events := []testEvent{{Name: "launch", At: at}}
+	query := `{ events { at ends } }`
+
+	tests := []struct {
+		opts []Option
+		want string
+	}{
+		{want: `{"data": {"events": [{"at": 1706698800000, "ends": null}]}}`},
+		{opts: []Option{WithTimeFormat(TimeUnixMillis)}, want: `{"data": {"events": [{"at": 1706698800000, "ends": null}]}}`},
+		{opts: []Option{WithTimeFormat(TimeUnixSeconds)}, want: `{"data": {"events": [{"at": 1706698800, "ends": null}]}}`},
+		{opts: []Option{WithTimeFormat(TimeRFC3339)}, want: `{"data": {"events": [{"at": "2024-01-31T12:00:00+01:00", "ends": null}]}}`},
+	}
+	for _, test := range tests {
+		b, err := QueryStructViaGraphql("events", events, query, test.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertJSON(t, b, test.want)
+	}
+
+	sdl, err := SchemaSDL("events", events, WithTimeFormat(TimeRFC3339))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sdl, "at: String\n") {
+		t.Errorf("SDL doesn't expose the RFC 3339 time as String:\n%s", sdl)
+	}
+}
+
+func TestFunctionFieldArguments(t *testing.T) {
+	shop := testShop{Name: "corner", Offers: func(ctx context.Context, self testShop, args testCountArgs) ([]Cat, error) {
+		user, _ := ctx.Value(testUserKey{}).(string)
+		if user == "" {
+			return nil, errors.New("no user in the context of " + self.Name)
+		}
+		return cats[:min(args.Count, len(cats))], nil
+	}}
+
+	ctx := context.WithValue(context.Background(), testUserKey{}, "ann")
+	b, err := QueryStructViaGraphqlContext(ctx, "shop", shop, `{ shop { two: offers(count: 2) { name } none: offers { name } } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"shop": {"two": [{"name": "Maru"}, {"name": "Hana"}], "none": []}}}`)
+
+	_, err = QueryStructViaGraphqlContext(context.Background(), "shop", shop, `{ shop { offers(count: 1) { name } } }`)
+	if err == nil || err.Error() != "no user in the context of corner" {
+		t.Errorf("got error %v, want the error of the function", err)
+	}
+
+	for _, query := range []string{`{ shop { offers(count: 1.5) { name } } }`, `{ shop { offers(count: "1") { name } } }`} {
+		if b, err := QueryStructViaGraphqlContext(ctx, "shop", shop, query); err == nil {
+			t.Errorf("%s: the argument was accepted: %s", query, b)
+		}
+	}
+}
+
+func TestQueryStructViaGraphqlContextCanceled(t *testing.T) {
+	calls := 0
+	ctx, cancel := context.WithCancel(context.Background())
+	shop := testShop{Name: "corner", Offers: func(ctx context.Context, self testShop, args testCountArgs) ([]Cat, error) {
+		calls++
+		cancel()
+		return cats, nil
+	}}
+
+	// The function cancels the context, so the second one isn't called
+	_, err := QueryStructViaGraphqlContext(ctx, "shop", shop, `{ shop { a: offers { name } b: offers { name } } }`)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+	if calls != 1 {
+		t.Errorf("the function was called %d times after the context was canceled", calls-1)
+	}
+
+	// A context canceled before the query fails it without calling the function
+	_, err = QueryStructViaGraphqlContext(ctx, "shop", shop, `{ shop { offers { name } } }`)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+	if calls != 1 {
+		t.Errorf("the function was called for a canceled context")
+	}
+}
+
+func TestEnumConstants(t *testing.T) {
+	lamps := []testLamp{{Name: "desk", Shade: shadeWarm}, {Name: "porch", Shade: shadeCold}}
+	withEnum := WithEnum(shadeWarm, shadeCold)
+
+	b, err := QueryStructViaGraphql("lamps", lamps, `{ __type(name: "testShade") { kind } lamps(where: {shade: warm}) { name shade } }`, withEnum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"__type": {"kind": "ENUM"}, "lamps": [{"name": "desk", "shade": "warm"}]}}`)
+
+	// graphql-go keeps the enum values in a map, so their order varies
+	b, err = QueryStructViaGraphql("lamps", lamps, `{ __type(name: "testShade") { enumValues { name } } }`, withEnum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result struct {
+		Data struct {
+			Type struct {
+				EnumValues []struct{ Name string }
+			} `json:"__type"`
+		}
+	}
+	if err := json.Unmarshal(b, &result); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, value := range result.Data.Type.EnumValues {
+		names = append(names, value.Name)
+	}
+	sort.Strings(names)
+	if !reflect.DeepEqual(names, []string{"cold", "warm"}) {
+		t.Errorf("got enum values %v, want cold and warm", names)
+	}
+
+	for _, query := range []string{
+		`{ lamps(where: {shade: neon}) { name } }`,
+		`{ lamps(where: {shade: "warm"}) { name } }`,
+	} {
+		if b, err := QueryStructViaGraphql("lamps", lamps, query, withEnum); err == nil {
+			t.Errorf("%s: the invalid enum value was accepted: %s", query, b)
+		}
+	}
+	if b, err := QueryStructViaGraphql("lamps", lamps, `query($s: testShade) { lamps(where: {shade: $s}) { name } }`, withEnum, WithVariables(map[string]any{"s": "neon"})); err == nil {
+		t.Errorf("the invalid enum variable was accepted: %s", b)
+	}
+}
+
+func TestFilterOperatorObjects(t *testing.T) {
+	size := 30
+	collars := []testCollar{{Name: "red", Owner: &cats[0], Size: &size}, {Name: "blue"}}
+
+	tests := []struct {
+		root  string
+		where string
+		want  string
+	}{
+		{"cats", `{age: {eq: 2}}`, `["Lily"]`},
+		{"cats", `{age: {ne: 2}}`, `["Maru", "Hana"]`},
+		{"cats", `{age: {gt: 2}}`, `["Maru"]`},
+		{"cats", `{age: {gte: 2}}`, `["Maru", "Lily"]`},
+		{"cats", `{age: {lt: 2}}`, `["Hana"]`},
+		{"cats", `{age: {lte: 2}}`, `["Hana", "Lily"]`},
+		{"cats", `{age: {gt: 1, lt: 3}}`, `["Lily"]`},
+		{"cats", `{name: {eq: "Maru"}}`, `["Maru"]`},
+		{"cats", `{name: {ne: "Maru"}}`, `["Hana", "Lily"]`},
+		{"cats", `{name: {contains: "a"}}`, `["Maru", "Hana"]`},
+		{"cats", `{name: {startsWith: "L"}}`, `["Lily"]`},
+		{"cats", `{name: {regex: "^[HL]"}}`, `["Hana", "Lily"]`},
+		{"cats", `{name: {isNull: true}}`, `[]`},
+		{"cats", `{_or: [{age: {lt: 2}}, {color: {eq: "White"}}]}`, `["Maru", "Hana"]`},
+		{"collars", `{size: {isNull: true}}`, `["blue"]`},
+		{"collars", `{size: {isNull: false, gt: 20}}`, `["red"]`},
+		{"collars", `{size: {lt: 100}}`, `["red"]`},
+
+		// Struct fields are still filtered by the fields of the struct
+		{"collars", `{owner: {name: {startsWith: "Ma"}}}`, `["red"]`},
+		{"collars", `{owner_isNull: true}`, `["blue"]`},
+	}
+
+	lists := map[string]any{"cats": cats, "collars": collars}
+	for _, test := range tests {
+		b, err := QueryStructViaGraphql(test.root, lists[test.root], `{ `+test.root+`(where: `+test.where+`) { name } }`, WithFilterOperatorObjects())
+		if err != nil {
+			t.Errorf("%s: %v", test.where, err)
+			continue
+		}
+
+		var result struct {
+			Data map[string][]struct{ Name string }
+		}
+		if err := json.Unmarshal(b, &result); err != nil {
+			t.Fatal(err)
+		}
+		names := []string{}
+		for _, element := range result.Data[test.root] {
+			names = append(names, element.Name)
+		}
+		got, _ := json.Marshal(names)
+		assertJSON(t, got, test.want)
+	}
+
+	// The suffixed fields aren't generated
+	if b, err := QueryStructViaGraphql("cats", cats, `{ cats(where: {age_gt: 1}) { name } }`, WithFilterOperatorObjects()); err == nil {
+		t.Errorf("the suffixed operator field was accepted: %s", b)
+	}
+
+	// Constraints and regular expressions are checked like without the option
+	_, err := QueryStructViaGraphql("parcels", parcels, `{ parcels(where: {size: {eq: 11}}) { label } }`, WithFilterOperatorObjects())
+	if err == nil || err.Error() != "where.size.eq must be <= 10, got 11" {
+		t.Errorf("got error %v, want the constraint of size to fail", err)
+	}
+	_, err = QueryStructViaGraphql("cats", cats, `{ cats(where: {name: {regex: "(("}}) { name } }`, WithFilterOperatorObjects())
+	if err == nil || !strings.HasPrefix(err.Error(), "where.name.regex: error parsing regexp") {
+		t.Errorf("got error %v, want the invalid pattern to fail", err)
+	}
+
+	sdl, err := SchemaSDL("cats", cats, WithFilterOperatorObjects())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, definition := range []string{"age: FloatOperators", "input StringOperators {", "startsWith: String", "regex: Regex", "isNull: Boolean"} {
+		if !strings.Contains(sdl, definition) {
+			t.Errorf("SDL doesn't contain %q:\n%s", definition, sdl)
+		}
+	}
+}
+
+func TestSkipAndLimit(t *testing.T) {
+	tests := []struct {
+		list []Cat
+		args string
+		want string
+	}{
+		{list: []Cat{}, args: `skip: 1`, want: `[]`},
+		{list: []Cat{}, args: `skip: 0, limit: 2`, want: `[]`},
+		{list: nil, args: `skip: 2`, want: `[]`},
+		{list: cats, args: `skip: 1`, want: `[{"name": "Hana"}, {"name": "Lily"}]`},
+		{list: cats, args: `skip: 3`, want: `[]`},
+		{list: cats, args: `skip: 10, limit: 2`, want: `[]`},
+		{list: cats, args: `skip: -1`, want: `[{"name": "Maru"}, {"name": "Hana"}, {"name": "Lily"}]`},
+		{list: cats, args: `skip: -5, limit: 1`, want: `[{"name": "Maru"}]`},
+		{list: cats, args: `limit: 10`, want: `[{"name": "Maru"}, {"name": "Hana"}, {"name": "Lily"}]`},
+		{list: cats, args: `limit: -1`, want: `[]`},
+		{list: cats, args: `skip: 2, limit: 5`, want: `[{"name": "Lily"}]`},
+	}
+
+	for _, test := range tests {
+		b, err := QueryStructViaGraphql("litter", testLitter{Cats: test.list}, `{ litter { cats(`+test.args+`) { name } } }`)
+		if err != nil {
+			t.Errorf("%s: %v", test.args, err)
+			continue
+		}
+		assertJSON(t, b, `{"data": {"litter": {"cats": `+test.want+`}}}`)
+	}
+}
+
+func TestNestedFilters(t *testing.T) {
+	walkers := []testWalker{{Name: "Ann", Dog: &dogs[0]}, {Name: "Ben", Dog: &dogs[2]}, {Name: "Cid"}}
+	tests := []struct {
+		root  string
+		where string
+		want  string
+	}{
+		// One level deep
+		{"dogs", `{friend: {name: "Maru"}}`, `[{"name": "Bello"}, {"name": "Momo"}]`},
+		{"dogs", `{friend: {name: "Maru"}, color: "White"}`, `[{"name": "Momo"}]`},
+		{"dogs", `{friend: {age_lt: 2}}`, `[{"name": "Kuro"}]`},
+		{"dogs", `{friend: {_or: [{name: "Hana"}, {color: "Purple"}]}}`, `[{"name": "Kuro"}]`},
+		{"walkers", `{dog: {color: "Gray"}}`, `[{"name": "Ben"}]`},
+
+		// Two levels deep, nil pointers on the way don't match
+		{"walkers", `{dog: {friend: {name: "Maru"}}}`, `[{"name": "Ann"}]`},
+		{"walkers", `{dog: {friend: {name: "Lily"}}}`, `[]`},
+		{"walkers", `{dog: {friend: {age_gte: 1}, age: 1}}`, `[{"name": "Ben"}]`},
+		{"walkers", `{dog_isNull: true}`, `[{"name": "Cid"}]`},
+	}
+
+	lists := map[string]any{"dogs": dogs, "walkers": walkers}
+	for _, test := range tests {
+		b, err := QueryStructViaGraphql(test.root, lists[test.root], `{ `+test.root+`(where: `+test.where+`) { name } }`)
+		if err != nil {
+			t.Errorf("%s: %v", test.where, err)
+			continue
+		}
+		assertJSON(t, b, `{"data": {"`+test.root+`": `+test.want+`}}`)
+	}
+}
+
+func TestDistinct(t *testing.T) {
+	litter := []Cat{
+		{Name: "Maru", Age: 3, Color: "White"},
+		{Name: "Hana", Age: 1, Color: "Gray"},
+		{Name: "Yuki", Age: 2, Color: "White"},
+		{Name: "Lily", Age: 2, Color: "Black"},
+		{Name: "Sora", Age: 1, Color: "Gray"},
+	}
+	b, err := QueryStructViaGraphql("cats", litter, `{
+		color: cats(distinct: "color") { name }
+		age: cats(distinct: "age") { name }
+		filtered: cats(distinct: "color", where: {age_lt: 3}) { name }
+		sorted: cats(distinct: "color", orderBy: {field: "name", direction: DESC}) { name }
+		limited: cats(distinct: "color", skip: 1, limit: 1) { name }
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {
+		"color": [{"name": "Maru"}, {"name": "Hana"}, {"name": "Lily"}],
+		"age": [{"name": "Maru"}, {"name": "Hana"}, {"name": "Yuki"}],
+		"filtered": [{"name": "Hana"}, {"name": "Yuki"}, {"name": "Lily"}],
+		"sorted": [{"name": "Yuki"}, {"name": "Sora"}, {"name": "Lily"}],
+		"limited": [{"name": "Hana"}]
+	}}`)
+
+	if b, err := QueryStructViaGraphql("cats", litter, `{ cats(distinct: "weight") { name } }`); err == nil {
+		t.Errorf("distinct by an unknown field succeeded: %s", b)
+	}
+}
+
+func TestMutationStructViaGraphql(t *testing.T) {
+	store := testDogStore{Dogs: []Dog{{Name: "Bello", Age: 2}, {Name: "Momo", Age: 3}}}
+
+	b, err := MutationStructViaGraphql("store", &store, `mutation { rename(from: "Momo", to: "Rex") { name age } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"rename": {"name": "Rex", "age": 3}}}`)
+	if store.Dogs[1].Name != "Rex" {
+		t.Errorf("got name %s, want the method to have renamed the dog", store.Dogs[1].Name)
+	}
+
+	// Queries see the change, value methods are fields of the query
+	b, err = MutationStructViaGraphql("store", &store, `{ store { dogs { name } count } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"store": {"dogs": [{"name": "Bello"}, {"name": "Rex"}], "count": 2}}}`)
+
+	_, err = MutationStructViaGraphql("store", &store, `mutation { rename(from: "Momo", to: "Max") { name } }`)
+	if err == nil || err.Error() != "no dog named Momo" {
+		t.Errorf("got error %v, want the error of the method", err)
+	}
+	if b, err := MutationStructViaGraphql("store", &store, `mutation { count }`); err == nil {
+		t.Errorf("the value method was exposed as a mutation: %s", b)
+	}
+}
+
+func TestFilterIntegerWidths(t *testing.T) {
+	widths := []testWidths{
+		{Name: "two", I: 2, I8: 2, I16: 2, I32: 2, I64: 2, U: 2, U8: 2, U16: 2, U32: 2, U64: 2, Named: 2},
+		{Name: "three", I: 3, I8: 3, I16: 3, I32: 3, I64: 3, U: 3, U8: 3, U16: 3, U32: 3, U64: 3, Named: 3},
+	}
+
+	for _, field := range []string{"i", "i8", "i16", "i32", "i64", "u", "u8", "u16", "u32", "u64", "named"} {
+		t.Run(field, func(t *testing.T) {
+			b, err := QueryStructViaGraphql("widths", widths, fmt.Sprintf(`{ widths(where: {%s: 2}) { name } }`, field))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertJSON(t, b, `{"data": {"widths": [{"name": "two"}]}}`)
+		})
+	}
+}
+
+func TestSmallInts(t *testing.T) {
+	widths := []testWidths{{Name: "two", I: 2, I8: -2, I16: 2, I32: 2, U8: 2, U16: 2, U32: 2, Named: 2}}
+
+	sdl, err := SchemaSDL("widths", widths, WithSmallInts())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// int, uint and uint32 may exceed 32 bits and stay Float
+	for _, field := range []string{"i8: Int\n", "i16: Int\n", "i32: Int\n", "u8: Int\n", "u16: Int\n", "named: Int\n", "i: Float\n", "u: Float\n", "u32: Float\n"} {
+		if !strings.Contains(sdl, field) {
+			t.Errorf("SDL doesn't contain %q:\n%s", field, sdl)
+		}
+	}
+
+	b, err := QueryStructViaGraphql("widths", widths, `{ widths(where: {i8: -2, u16_gt: 1}) { i8 i16 i32 u8 u16 named } }`, WithSmallInts())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// assertJSON compares the numbers literally, so 2.0 wouldn't match
+	assertJSON(t, b, `{"data": {"widths": [{"i8": -2, "i16": 2, "i32": 2, "u8": 2, "u16": 2, "named": 2}]}}`)
+}
+
+func TestTagPrecedence(t *testing.T) {
+	members := []testMember{{Handle: "ann", Display: "Ann", Avatar: "ann.png", Region: "eu", Token: "secret"}}
+
+	// graphql tag, then json tag, then the lowercased Go name
+	b, err := QueryStructViaGraphql("members", members, `{ members(where: {handle: "ann", display_name: "Ann", picture: "ann.png", zone: "eu"}) { handle display_name picture zone } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"members": [{"handle": "ann", "display_name": "Ann", "picture": "ann.png", "zone": "eu"}]}}`)
+
+	for _, query := range []string{
+		`{ members { display } }`,
+		`{ members { avatar } }`,
+		`{ members { region_code } }`,
+		`{ members { token } }`,
+		`{ members(where: {token: "secret"}) { handle } }`,
+	} {
+		if b, err := QueryStructViaGraphql("members", members, query); err == nil {
+			t.Errorf("%s: the field was exposed: %s", query, b)
+		}
+	}
+
+	// graphql:"-" only hides the field from GraphQL
+	encoded, err := json.Marshal(members[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(encoded), `"token":"secret"`) {
+		t.Errorf("the token wasn't encoded to JSON: %s", encoded)
+	}
+}
+
+func TestMaxQueryDepth(t *testing.T) {
+	// dogs, friend and name are three fields deep, also through the fragment
+	for _, query := range []string{
+		`{ dogs(where: {name: "Kuro"}) { friend { name } } }`,
+		`{ dogs(where: {name: "Kuro"}) { ...friendName } } fragment friendName on Dog { friend { name } }`,
+	} {
+		b, err := QueryStructViaGraphql("dogs", dogs, query, WithMaxQueryDepth(3))
+		if err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+		assertJSON(t, b, `{"data": {"dogs": [{"friend": {"name": "Hana"}}]}}`)
+
+		_, err = QueryStructViaGraphql("dogs", dogs, query, WithMaxQueryDepth(2))
+		if err == nil || err.Error() != "query is nested 3 fields deep, exceeding the max depth of 2" {
+			t.Errorf("%s: got error %v, want the depth to be exceeded", query, err)
+		}
+	}
+}
+
+func TestOmitEmpty(t *testing.T) {
+	badges := []testBadge{{}, {Label: "gold", Level: 3, Active: true, Tags: []string{"top"}, Rank: 1, Note: "first"}}
+	b, err := QueryStructViaGraphql("badges", badges, `{ badges { label level active tags rank note } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the empty omitempty fields are null, the others keep their zero values
+	assertJSON(t, b, `{"data": {"badges": [
+		{"label": null, "level": null, "active": null, "tags": null, "rank": 0, "note": ""},
+		{"label": "gold", "level": 3, "active": true, "tags": ["top"], "rank": 1, "note": "first"}
+	]}}`)
+}
+
+func TestNestedLists(t *testing.T) {
+	grid := testGrid{
+		Matrix: [][]int{{1, 2}, {3}, {}},
+		Groups: [][]Cat{{cats[0], cats[1]}, {cats[2]}},
+		Fixed:  [2][2]int{{1, 2}, {3, 4}},
+	}
+	b, err := QueryStructViaGraphql("grid", grid, `{ grid { matrix groups { name } fixed } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"grid": {"matrix": [[1, 2], [3], []], "groups": [[{"name": "Maru"}, {"name": "Hana"}], [{"name": "Lily"}]], "fixed": [[1, 2], [3, 4]]}}}`)
+
+	// skip and limit apply to the outer list
+	b, err = QueryStructViaGraphql("grid", grid, `{ grid { matrix(limit: 1) groups(skip: 1) { name } } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"grid": {"matrix": [[1, 2]], "groups": [[{"name": "Lily"}]]}}}`)
+
+	sdl, err := SchemaSDL("grid", grid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// No where or lookup arguments, the fields of Cat are two lists deep
+	for _, field := range []string{
+		"matrix(first: Int, last: Int, limit: Int, skip: Int): [[Float]]",
+		"groups(first: Int, last: Int, limit: Int, skip: Int): [[Cat]]",
+	} {
+		if !strings.Contains(sdl, field) {
+			t.Errorf("SDL doesn't contain %q:\n%s", field, sdl)
+		}
+	}
+}
+
+func TestFunctionFieldListArguments(t *testing.T) {
+	calls := 0
+	pack := []Dog{{Name: "Rex", Enemies: func(self Dog) ([]Cat, error) {
+		calls++
+		return cats, nil
+	}}}
+
+	b, err := QueryStructViaGraphql("dogs", pack, `{ dogs {
+		enemies { name }
+		first: enemies(limit: 1) { name }
+		rest: enemies(skip: 1) { name }
+		young: enemies(where: {age_lt: 3}, limit: 1) { name }
+	} }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"dogs": [{
+		"enemies": [{"name": "Maru"}, {"name": "Hana"}, {"name": "Lily"}],
+		"first": [{"name": "Maru"}],
+		"rest": [{"name": "Hana"}, {"name": "Lily"}],
+		"young": [{"name": "Hana"}]
+	}]}}`)
+	if calls != 4 {
+		t.Errorf("got %d calls, want one per aliased field", calls)
+	}
+}
+
+func TestSchemaBuilder(t *testing.T) {
+	at := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	events := []testEvent{{Name: "launch", At: at}, {Name: "landing", At: at.Add(time.Hour)}}
+
+	builder := NewSchemaBuilder(WithTimeFormat(TimeRFC3339), WithMaxQueryDepth(2))
+	schema, err := builder.Build("events", events)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := builder.Execute(schema, `{ events { name at } }`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"events": [{"name": "launch", "at": "2024-01-31T12:00:00Z"}, {"name": "landing", "at": "2024-01-31T13:00:00Z"}]}}`)
+
+	// The variables belong to each query, the data is read when it is executed
+	query := `query ($name: String) { events(where: {name: $name}) { name } }`
+	events[1].Name = "touchdown"
+	b, err = builder.Execute(schema, query, map[string]any{"name": "touchdown"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"events": [{"name": "touchdown"}]}}`)
+
+	b, err = builder.Execute(schema, query, map[string]any{"name": "launch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"events": [{"name": "launch"}]}}`)
+
+	if _, err := builder.Execute(schema, `{ __schema { types { name } } }`, nil); err == nil {
+		t.Error("the max depth option of the builder wasn't applied")
+	}
+
+	other, err := BuildSchema("events", events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := builder.Execute(other, `{ events { name } }`, nil); err == nil || err.Error() != "schema was not built by this builder" {
+		t.Errorf("got error %v, want the foreign schema to be rejected", err)
+	}
+}
+
+func TestNilSlicesAsNull(t *testing.T) {
+	shelves := []testShelf{{Name: "nil"}, {Name: "empty", Tags: []string{}, Cats: []Cat{}}}
+	query := `{ shelves { name tags cats { name } } }`
+
+	b, err := QueryStructViaGraphql("shelves", shelves, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"shelves": [{"name": "nil", "tags": [], "cats": []}, {"name": "empty", "tags": [], "cats": []}]}}`)
+
+	b, err = QueryStructViaGraphql("shelves", shelves, query, WithNilSlicesAsNull())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"shelves": [{"name": "nil", "tags": null, "cats": null}, {"name": "empty", "tags": [], "cats": []}]}}`)
+}
+
+func TestJSONStringNumbers(t *testing.T) {
+	orders := []testOrder{{ID: 9007199254740993, Total: 1.5, Items: 2}, {ID: 7, Total: 20, Items: 1}}
+
+	b, err := QueryStructViaGraphql("orders", orders, `{ orders { id total items } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"orders": [{"id": "9007199254740993", "total": "1.5", "items": 2}, {"id": "7", "total": "20", "items": 1}]}}`)
+
+	// Formatted like encoding/json
+	encoded, err := json.Marshal(orders[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, encoded, `{"id": "9007199254740993", "total": "1.5", "Items": 2}`)
+
+	b, err = QueryStructViaGraphql("orders", orders, `{ id: orders(where: {id: "9007199254740993"}) { items } total: orders(where: {total: "20.0"}) { items } prefix: orders(where: {id_startsWith: "900"}) { items } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"id": [{"items": 2}], "total": [{"items": 1}], "prefix": [{"items": 2}]}}`)
+
+	sdl, err := SchemaSDL("orders", orders)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"id: String\n", "total: String\n", "items: Float\n"} {
+		if !strings.Contains(sdl, field) {
+			t.Errorf("SDL doesn't contain %q:\n%s", field, sdl)
+		}
+	}
+}
+
+func TestDescriptions(t *testing.T) {
+	puppies := []testPuppy{{Name: "Pip", Age: 1}}
+	b, err := QueryStructViaGraphql("puppies", puppies, `{ __type(name: "testPuppy") { description fields { name description args { name description } } } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result struct {
+		Data struct {
+			Type struct {
+				Description string
+				Fields      []struct {
+					Name        string
+					Description string
+					Args        []struct {
+						Name        string
+						Description string
+					}
+				}
+			} `json:"__type"`
+		}
+	}
+	if err := json.Unmarshal(b, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Data.Type.Description != "A puppy of the kennel" {
+		t.Errorf("got type description %q", result.Data.Type.Description)
+	}
+
+	descriptions := map[string]string{}
+	for _, field := range result.Data.Type.Fields {
+		if field.Description != "" {
+			descriptions[field.Name] = field.Description
+		}
+		for _, arg := range field.Args {
+			if arg.Description != "" {
+				descriptions[field.Name+"."+arg.Name] = arg.Description
+			}
+		}
+	}
+	want := map[string]string{
+		"name":        "The display name of the puppy",
+		"trick":       "Shows a trick",
+		"trick.times": "How often the trick is shown",
+	}
+	if !reflect.DeepEqual(descriptions, want) {
+		t.Errorf("got descriptions %v, want %v", descriptions, want)
+	}
+
+	// Filter fields are described like the fields
+	b, err = QueryStructViaGraphql("puppies", puppies, `{ __type(name: "puppies") { inputFields { name description } } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"description": "The display name of the puppy"`) {
+		t.Errorf("the name filter isn't described: %s", b)
+	}
+}
+
+func TestCompactJSON(t *testing.T) {
+	query := `{ cats(where: {name: "Maru"}) { name age } }`
+	b, err := QueryStructViaGraphql("cats", cats, query, WithCompactJSON())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"data":{"cats":[{"age":3,"name":"Maru"}]}}`; string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+
+	b, err = QueryStructViaGraphql("cats", cats, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(b, []byte("\n  \"data\": {")) {
+		t.Errorf("the default result isn't indented: %s", b)
+	}
+}
+
+func TestFunctionFieldResults(t *testing.T) {
+	pups := []testPup{
+		{
+			Name:  "Pip",
+			Greet: func(self testPup) string { return "woof from " + self.Name },
+			Best:  func(self testPup) (*Cat, error) { return &cats[1], nil },
+		},
+		{
+			Name:  "Bo",
+			Greet: func(self testPup) string { return "woof from " + self.Name },
+			Best:  func(self testPup) (*Cat, error) { return nil, errors.New("Bo has no best friend") },
+		},
+	}
+
+	b, err := QueryStructViaGraphql("pups", pups[:1], `{ pups { greet best { name } } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"pups": [{"greet": "woof from Pip", "best": {"name": "Hana"}}]}}`)
+
+	_, err = QueryStructViaGraphql("pups", pups[1:], `{ pups { greet best { name } } }`)
+	if err == nil || err.Error() != "Bo has no best friend" {
+		t.Errorf("got error %v, want the error of the function", err)
+	}
+
+	_, err = BuildSchema("pups", []testMutePup{})
+	if want := "function field Close of testMutePup: must return a value, optionally followed by an error"; err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %q", err, want)
+	}
+}
+
+func TestBoolFilter(t *testing.T) {
+	toggles := []testToggle{{Name: "a", Enabled: true, Active: false}, {Name: "b", Enabled: false, Active: true}}
+	b, err := QueryStructViaGraphql("toggles", toggles, `{
+		enabled: toggles(where: {enabled: true}) { name }
+		disabled: toggles(where: {enabled: false}) { name }
+		active: toggles(where: {active: true}) { name }
+		inactive: toggles(where: {active_ne: true}) { name }
+		both: toggles(where: {enabled: true, active: true}) { name }
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {
+		"enabled": [{"name": "a"}],
+		"disabled": [{"name": "b"}],
+		"active": [{"name": "b"}],
+		"inactive": [{"name": "a"}],
+		"both": []
+	}}`)
+}
+
+func TestDurations(t *testing.T) {
+	rest := 5 * time.Minute
+	walks := []testWalk{
+		{Route: "park", Length: 90 * time.Minute, Splits: []time.Duration{time.Hour, 30 * time.Minute}, Rest: &rest},
+		{Route: "block", Length: 1500 * time.Millisecond},
+	}
+
+	b, err := QueryStructViaGraphql("walks", walks, `{ walks { route length splits rest } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"walks": [
+		{"route": "park", "length": "1h30m0s", "splits": ["1h0m0s", "30m0s"], "rest": "5m0s"},
+		{"route": "block", "length": "1.5s", "splits": [], "rest": null}
+	]}}`)
+
+	// Filters parse the duration, so "90m" matches "1h30m0s"
+	b, err = QueryStructViaGraphql("walks", walks, `{ walks(where: {length: "90m"}) { route } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"walks": [{"route": "park"}]}}`)
+
+	sdl, err := SchemaSDL("walks", walks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sdl, "length: String\n") {
+		t.Errorf("SDL doesn't expose the duration as String:\n%s", sdl)
+	}
+}
+
+func TestFirstAndLast(t *testing.T) {
+	b, err := QueryStructViaGraphql("cats", cats, `{
+		first: cats(first: 2) { name }
+		last: cats(last: 2) { name }
+		both: cats(first: 2, last: 1) { name }
+		filtered: cats(where: {age_gt: 1}, last: 1) { name }
+		skipped: cats(skip: 1, first: 1) { name }
+		over: cats(first: 5, last: 5) { name }
+		none: cats(first: 0) { name }
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {
+		"first": [{"name": "Maru"}, {"name": "Hana"}],
+		"last": [{"name": "Hana"}, {"name": "Lily"}],
+		"both": [{"name": "Hana"}],
+		"filtered": [{"name": "Lily"}],
+		"skipped": [{"name": "Hana"}],
+		"over": [{"name": "Maru"}, {"name": "Hana"}, {"name": "Lily"}],
+		"none": []
+	}}`)
+}
+
+func TestTypeNames(t *testing.T) {
+	certificates := []testCertificate{{Tag: xml.Name{Local: "cert"}, Subject: pkix.Name{CommonName: "ann"}}}
+	certificates[0].Meta.Size = 2
+
+	// xml.Name and pkix.Name share their name
+	_, err := BuildSchema("certificates", certificates)
+	if want := "types encoding/xml.Name and crypto/x509/pkix.Name are both named Name, rename one with WithTypeName"; err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %q", err, want)
+	}
+
+	withName := WithTypeName(reflect.TypeOf(pkix.Name{}), "SubjectName")
+	b, err := QueryStructViaGraphql("certificates", certificates, `{ certificates {
+		tag { __typename local }
+		subject { __typename commonname }
+		meta { __typename size }
+	} }`, withName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The anonymous struct is named after its path
+	assertJSON(t, b, `{"data": {"certificates": [{
+		"tag": {"__typename": "Name", "local": "cert"},
+		"subject": {"__typename": "SubjectName", "commonname": "ann"},
+		"meta": {"__typename": "CertificatesMeta", "size": 2}
+	}]}}`)
+}
+
+func TestQueryStructViaGraphqlLazy(t *testing.T) {
+	calls := 0
+	load := func() ([]Cat, error) {
+		calls++
+		return cats, nil
+	}
+
+	b, err := QueryStructViaGraphqlLazy("cats", load, `{ __schema { queryType { name } } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"__schema": {"queryType": {"name": "RootQuery"}}}}`)
+	if calls != 0 {
+		t.Errorf("load was called %d times for a query without the root field", calls)
+	}
+
+	b, err = QueryStructViaGraphqlLazy("cats", load, `{ white: cats(where: {color: "White"}) { name } gray: cats(where: {color: "Gray"}) { name } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"white": [{"name": "Maru"}], "gray": [{"name": "Hana"}]}}`)
+	if calls != 1 {
+		t.Errorf("load was called %d times, want once for both aliases", calls)
+	}
+
+	_, err = QueryStructViaGraphqlLazy("cats", func() ([]Cat, error) {
+		return nil, errors.New("database is down")
+	}, `{ cats { name } }`)
+	if err == nil || err.Error() != "database is down" {
+		t.Errorf("got error %v, want the error of load", err)
+	}
+}
+
+func TestIsNullFilter(t *testing.T) {
+	size := 30
+	collars := []testCollar{{Name: "red", Owner: &cats[0], Size: &size}, {Name: "blue", Size: &size}, {Name: "green"}}
+	b, err := QueryStructViaGraphql("collars", collars, `{
+		unowned: collars(where: {owner_isNull: true}) { name }
+		owned: collars(where: {owner_isNull: false}) { name }
+		sized: collars(where: {owner_isNull: true, size_isNull: false}) { name }
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {
+		"unowned": [{"name": "blue"}, {"name": "green"}],
+		"owned": [{"name": "red"}],
+		"sized": [{"name": "blue"}]
+	}}`)
+
+	// Nil slices are null, empty ones aren't
+	shelves := []testShelf{{Name: "nil"}, {Name: "empty", Tags: []string{}}}
+	b, err = QueryStructViaGraphql("shelves", shelves, `{ null: shelves(where: {tags_isNull: true}) { name } set: shelves(where: {tags_isNull: false}) { name } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, b, `{"data": {"null": [{"name": "nil"}], "set": [{"name": "empty"}]}}`)
+}
+
+func TestJSONScalar(t *testing.T) {
+	hooks := []testWebhook{
+		{
+			Name:    "push",
+			Payload: json.RawMessage(`{"x": [1, 2], "id": 9007199254740993}`),
+			Context: map[string]any{"user": map[string]any{"name": "ann", "admin": true}},
+			Headers: map[string]any{"retries": 3},
+		},
+		{Name: "ping", Payload: json.RawMessage(`not json`)},
+	}
+
+	b, err := QueryStructViaGraphql("hooks", hooks, `{ hooks { name payload context headers } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Invalid raw messages are null, large integers keep their digits
+	assertJSON(t, b, `{"data": {"hooks": [
+		{"name": "push", "payload": {"x": [1, 2], "id": 9007199254740993}, "context": {"user": {"name": "ann", "admin": true}}, "headers": {"retries": 3}},
+		{"name": "ping", "payload": null, "context": null, "headers": null}
+	]}}`)
+
+	sdl, err := SchemaSDL("hooks", hooks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"payload: JSON\n", "context: JSON\n", "headers: JSON\n"} {
+		if !strings.Contains(sdl, field) {
+			t.Errorf("SDL doesn't contain %q:\n%s", field, sdl)
+		}
+	}
+}
+
+func TestMissingKeyPolicy(t *testing.T) {
+	for _, key := range []string{"3", "1.9"} {
+		_, err := QueryStructViaGraphql("shelter", shelter, `{ shelter { names(key: `+key+`) { value } } }`, WithMissingKeyPolicy(MissingKeyError))
+		if err == nil {
+			t.Errorf("looking up the missing key %s succeeded", key)
+		}
+	}
+}
+
+// Returns a struct type with width scalar fields and, above the last of the
+// given levels, 3 list fields of the struct type of the next level.
+func wideStructType(width, levels int) reflect.Type {
+	var fields []reflect.StructField
+	for i := 0; i < width; i++ {
+		t := []reflect.Type{reflect.TypeOf(""), reflect.TypeOf(0), reflect.TypeOf(0.0)}[i%3]
+		fields = append(fields, reflect.StructField{Name: fmt.Sprintf("F%d", i), Type: t})
+	}
+	if levels > 1 {
+		child := reflect.SliceOf(wideStructType(width, levels-1))
+		for i := 0; i < 3; i++ {
+			fields = append(fields, reflect.StructField{Name: fmt.Sprintf("C%d", i), Type: child})
+		}
+	}
+	return reflect.StructOf(fields)
+}
+
+// Compares building the fields of the objects eagerly, as buildSchema does,
+// with reflecting them lazily inside the thunk of the root object. Lazy
+// building doesn't save time, since graphql.NewSchema evaluates the thunks of
+// all reachable objects. 'reflect' is the share of the reflection alone.
+func BenchmarkBuildSchema(b *testing.B) {
+	t := wideStructType(100, 4)
+	root := reflect.New(t).Elem().Interface()
+
+	b.Run("eager", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := BuildSchema("root", root); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("lazy", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			options := newOptions(nil)
+			query := graphql.NewObject(graphql.ObjectConfig{
+				Name: "RootQuery",
+				Fields: graphql.FieldsThunk(func() graphql.Fields {
+					typ, _, err := createGraphQlFieldHierarchy(t, []string{"root"}, map[string]Pair[graphql.Output, graphql.Fields]{}, map[string]graphql.ArgumentConfig{}, options)
+					if err != nil {
+						b.Fatal(err)
+					}
+					return graphql.Fields{"root": &graphql.Field{Type: typ}}
+				}),
+			})
+			if _, err := graphql.NewSchema(graphql.SchemaConfig{Query: query}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("reflect", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _, err := createGraphQlFieldHierarchy(t, []string{"root"}, map[string]Pair[graphql.Output, graphql.Fields]{}, map[string]graphql.ArgumentConfig{}, newOptions(nil))
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// Checks that the SDL excerpt of the README is the current output of SchemaSDL.
+func TestSchemaSDLMatchesReadme(t *testing.T) {
+	readme, err := os.ReadFile("README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, excerpt, ok := strings.Cut(string(readme), "An excerpt of the output for the dogs of the example:\n\n```graphql\n")
+	if !ok {
+		t.Fatal("README has no SDL excerpt")
+	}
+	excerpt, _, _ = strings.Cut(excerpt, "```")
+
+	sdl, err := SchemaSDL("dogs", dogs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, definition := range strings.Split(strings.TrimSpace(excerpt), "\n\n") {
+		if !strings.Contains(sdl, definition) {
+			t.Errorf("SDL doesn't contain the definition of the README:\n%s\n\nSDL:\n%s", definition, sdl)
+		}
+	}
+}
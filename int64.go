@@ -0,0 +1,98 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// A 64-bit integer, used for int64 fields. Float represents integers exactly
+// only up to 2^53, which corrupts e.g. Snowflake IDs. The value is serialized
+// as a JSON number with all of its digits. Filter values can be given as
+// integer literals or as strings, e.g. for clients that can't produce such
+// numbers from variables.
+var int64Scalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "Int64",
+	Description: "The `Int64` scalar type represents a signed 64-bit integer without loss of precision.",
+	Serialize: func(value any) any {
+		r := reflect.ValueOf(value)
+		if r.CanInt() {
+			return r.Int()
+		}
+		return nil
+	},
+	ParseValue: func(value any) any {
+		return parseInt64Value(value, int64FromString)
+	},
+	ParseLiteral: func(valueAST ast.Value) any {
+		return parseInt64Literal(valueAST, int64FromString)
+	},
+})
+
+// An unsigned 64-bit integer, used for uint64 fields, see int64Scalar.
+var uint64Scalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "Uint64",
+	Description: "The `Uint64` scalar type represents an unsigned 64-bit integer without loss of precision.",
+	Serialize: func(value any) any {
+		r := reflect.ValueOf(value)
+		if r.CanUint() {
+			return r.Uint()
+		}
+		return nil
+	},
+	ParseValue: func(value any) any {
+		return parseInt64Value(value, uint64FromString)
+	},
+	ParseLiteral: func(valueAST ast.Value) any {
+		return parseInt64Literal(valueAST, uint64FromString)
+	},
+})
+
+func int64FromString(s string) any {
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil
+	}
+	return i
+}
+
+func uint64FromString(s string) any {
+	i, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return nil
+	}
+	return i
+}
+
+// Parses a variable value. Decoded JSON numbers are float64, which are only
+// accepted if they are integral and within the exactly representable range.
+func parseInt64Value(value any, parse func(string) any) any {
+	switch v := value.(type) {
+	case string:
+		return parse(v)
+	case int:
+		return parse(strconv.Itoa(v))
+	case int64:
+		return parse(strconv.FormatInt(v, 10))
+	case float64:
+		if v != math.Trunc(v) || math.Abs(v) > 1<<53 {
+			return nil
+		}
+		return parse(strconv.FormatFloat(v, 'f', 0, 64))
+	}
+	return nil
+}
+
+// Parses an integer or string literal without going through float64.
+func parseInt64Literal(valueAST ast.Value, parse func(string) any) any {
+	switch v := valueAST.(type) {
+	case *ast.IntValue:
+		return parse(v.Value)
+	case *ast.StringValue:
+		return parse(v.Value)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+
+	"github.com/graphql-go/graphql"
+)
+
+var (
+	typeAny        = reflect.TypeOf((*any)(nil)).Elem()
+	typeRawMessage = reflect.TypeOf(json.RawMessage{})
+	typeJSONObject = reflect.TypeOf(map[string]any{})
+)
+
+// Opaque JSON values of json.RawMessage, any and map[string]any fields,
+// which are returned as they are instead of being reflected into a schema.
+// Raw messages are decoded, so they are embedded into the result as JSON.
+// The scalar is output only, so these fields can't be filtered by.
+var jsonScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "JSON",
+	Description: "The `JSON` scalar type represents an arbitrary JSON value.",
+	Serialize: func(value any) any {
+		switch raw := value.(type) {
+		case json.RawMessage:
+			return decodeRawMessage(raw)
+		case *json.RawMessage:
+			if raw != nil {
+				return decodeRawMessage(*raw)
+			}
+			return nil
+		}
+		return value
+	},
+})
+
+// Returns true for the types that are exposed as the JSON scalar.
+func isJSONType(t reflect.Type) bool {
+	return t == typeAny || t == typeRawMessage || t == typeJSONObject
+}
+
+// Decodes the raw message with numbers kept as they are written, so large
+// integers don't lose precision. Empty and invalid messages are null.
+func decodeRawMessage(raw json.RawMessage) any {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Executes the query like QueryStructViaGraphql, but only calls load for
+// the root object once the query selects the root field, e.g. to load a
+// large list from a database only for the queries that need it:
+//
+//	b, err := QueryStructViaGraphqlLazy("dogs", func() ([]Dog, error) {
+//		return db.LoadDogs()
+//	}, query)
+//
+// The schema is built from the type T, so T has to be the concrete type of
+// the root object instead of an interface. load is called at most once per
+// query, also if the root field is selected several times under aliases,
+// and its error fails the root field. Queries selecting the root field are
+// never cached, see WithResultCache.
+func QueryStructViaGraphqlLazy[T any](rootField string, load func() (T, error), query string, opts ...Option) ([]byte, error) {
+	options := newOptions(opts)
+	options.ctx = context.Background()
+
+	loadOnce := sync.OnceValues(func() (any, error) {
+		return load()
+	})
+	return queryJSON(rootField, query, options, func() (graphql.Schema, error) {
+		return buildRootSchema(rootField, reflect.TypeOf((*T)(nil)).Elem(), loadOnce, funcField, options)
+	})
+}
@@ -66,13 +66,14 @@ var dogs = []Dog{
 
 func QueryDogs(c echo.Context) error {
 	var post struct {
-		Query string `json:"query"`
+		Query     string         `json:"query"`
+		Variables map[string]any `json:"variables"`
 	}
 	if err := c.Bind(&post); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
 	}
 
-	b, err := QueryStructViaGraphql("dogs", dogs, post.Query)
+	b, err := QueryStructViaGraphqlContext(c.Request().Context(), "dogs", dogs, post.Query, WithVariables(post.Variables))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
 	}
@@ -82,13 +83,14 @@ func QueryDogs(c echo.Context) error {
 
 func QueryCats(c echo.Context) error {
 	var post struct {
-		Query string `json:"query"`
+		Query     string         `json:"query"`
+		Variables map[string]any `json:"variables"`
 	}
 	if err := c.Bind(&post); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
 	}
 
-	b, err := QueryStructViaGraphql("cats", cats, post.Query)
+	b, err := QueryStructViaGraphqlContext(c.Request().Context(), "cats", cats, post.Query, WithVariables(post.Variables))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
 	}
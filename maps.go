@@ -0,0 +1,111 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// A single key/value pair of a map field. It is the
+// source of the generated key/value object resolvers.
+type mapEntry struct {
+	Key   reflect.Value
+	Value reflect.Value
+}
+
+// Returns the name of the key/value object generated for a map type,
+// e.g. "StringIntEntry" for map[string]int.
+func (o *options) mapEntryTypeName(t reflect.Type) string {
+	return o.typeNamePart(t.Key()) + o.typeNamePart(t.Elem()) + "Entry"
+}
+
+// Returns the name of t for the names of types derived from it,
+// which is the object name for renamed and anonymous structs.
+func (o *options) typeNamePart(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return "List" + o.typeNamePart(t.Elem())
+	case reflect.Pointer:
+		return o.typeNamePart(t.Elem())
+	case reflect.Map:
+		return "Map" + o.typeNamePart(t.Key()) + o.typeNamePart(t.Elem())
+	}
+
+	name := t.Name()
+	if objectName, ok := o.typeNames[t]; ok {
+		name = objectName
+	}
+	if name == "" {
+		name = t.Kind().String()
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// Returns all entries of the given map sorted by key, so the output doesn't
+// depend on Go's map iteration order. Enum keys are sorted by the declared
+// order of the enum values instead.
+func mapEntries(r reflect.Value, options *options) []mapEntry {
+	entries := make([]mapEntry, 0, r.Len())
+	iter := r.MapRange()
+	for iter.Next() {
+		entries = append(entries, mapEntry{Key: iter.Key(), Value: iter.Value()})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return lessMapKey(entries[i].Key, entries[j].Key)
+	})
+
+	if enum, _ := options.enum(r.Type().Key()); enum != nil {
+		sort.SliceStable(entries, func(i, j int) bool {
+			return enum.position(entries[i].Key) < enum.position(entries[j].Key)
+		})
+	}
+	return entries
+}
+
+func lessMapKey(x, y reflect.Value) bool {
+	switch x.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return x.Int() < y.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return x.Uint() < y.Uint()
+	case reflect.Float32, reflect.Float64:
+		return x.Float() < y.Float()
+	case reflect.Bool:
+		return !x.Bool() && y.Bool()
+	case reflect.String:
+		return x.String() < y.String()
+	}
+	return false
+}
+
+// Looks up the entry for a 'key' argument. The argument has the type from
+// getBasicOutput, e.g. float64 for integer keys, and is converted to the
+// key type of the map first. Keys that don't convert exactly, e.g. 1.9 for
+// an integer key, aren't found instead of matching the truncated key 1.
+func lookupMapEntry(r reflect.Value, key any) (mapEntry, bool) {
+	k, ok := convertExactly(reflect.ValueOf(key), r.Type().Key())
+	if !ok {
+		return mapEntry{}, false
+	}
+
+	v := r.MapIndex(k)
+	if !v.IsValid() {
+		return mapEntry{}, false
+	}
+	return mapEntry{Key: k, Value: v}, true
+}
+
+// Converts v to the type t, or returns false if the conversion loses
+// information, i.e. converting the result back doesn't give v again.
+func convertExactly(v reflect.Value, t reflect.Type) (reflect.Value, bool) {
+	if !v.IsValid() || !v.CanConvert(t) {
+		return v, false
+	}
+
+	converted := v.Convert(t)
+	if !converted.CanConvert(v.Type()) || !converted.Convert(v.Type()).Equal(v) {
+		return v, false
+	}
+	return converted, true
+}
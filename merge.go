@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/graphql-go/graphql"
+)
+
+// The value of an object that combines the fields of several structs,
+// holding one value per struct in the order the types were registered.
+// Declare a named type for each combination and register it via WithMergedStructs:
+//
+//	type DogWithOwner Merged
+//
+//	view := DogWithOwner{dog, owner}
+type Merged []any
+
+// Exposes the named type t, whose underlying type is Merged, as a single GraphQL
+// object with the fields of all given struct types. Each field is resolved from
+// the value of the struct that declares it. Building the schema fails if two
+// of the structs have a field with the same name.
+func WithMergedStructs(t reflect.Type, types ...reflect.Type) Option {
+	return func(o *options) {
+		if o.mergedStructs == nil {
+			o.mergedStructs = map[reflect.Type][]reflect.Type{}
+		}
+		o.mergedStructs[t] = types
+	}
+}
+
+func createMergedObject(t reflect.Type, types []reflect.Type, path []string, typesMap map[string]Pair[graphql.Output, graphql.Fields], filterMap map[string]graphql.ArgumentConfig, options *options) (graphql.Output, graphql.Fields, error) {
+	if t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Interface {
+		return nil, nil, fmt.Errorf("merged type %s must have Merged as underlying type", t.Name())
+	}
+
+	if err := options.claimTypeName(t.Name(), t); err != nil {
+		return nil, nil, err
+	}
+
+	fields := graphql.Fields{}
+	o := graphql.NewObject(graphql.ObjectConfig{
+		Name: t.Name(),
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return fields
+		}),
+	})
+
+	typesMap[t.Name()] = Pair[graphql.Output, graphql.Fields]{First: o, Second: fields}
+
+	// Maps the GraphQL field names to the struct that declares them
+	declaredBy := map[string]reflect.Type{}
+
+	for i, partType := range types {
+		if partType.Kind() != reflect.Struct {
+			return nil, nil, fmt.Errorf("merged type %s can only contain structs, got %s", t.Name(), partType)
+		}
+
+		_, partFields, err := createGraphQlFieldHierarchy(partType, path, typesMap, filterMap, options)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for name, partField := range partFields {
+			if other, ok := declaredBy[name]; ok {
+				return nil, nil, fmt.Errorf("field %q of merged type %s is declared by both %s and %s", name, t.Name(), other.Name(), partType.Name())
+			}
+			declaredBy[name] = partType
+
+			// Value copy to ensure proper capturing of variable in Resolve closure.
+			index := i
+			resolve := partField.Resolve
+
+			field := *partField
+			field.Resolve = func(p graphql.ResolveParams) (any, error) {
+				// Resolve the field from the value of the declaring struct
+				r := reflect.ValueOf(p.Source)
+				if index >= r.Len() || r.Index(index).IsNil() {
+					return nil, nil
+				}
+
+				p.Source = r.Index(index).Interface()
+				return resolve(p)
+			}
+			fields[name] = &field
+		}
+	}
+
+	return o, fields, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Executes the query or mutation against the object o. Besides the query
+// schema of QueryStructViaGraphql, the methods of o with a pointer receiver
+// are exposed as fields of the mutation root:
+//
+//	type RenameArgs struct {
+//		Name string
+//	}
+//
+//	func (k *Kennel) Rename(args RenameArgs) (Dog, error)
+//
+//	MutationStructViaGraphql("kennel", &kennel, `mutation { rename(name: "Rex") { name } }`)
+//
+// The methods follow the calling convention of function fields without the
+// self parameter, see funcSignature, since Go doesn't retain the names of
+// parameters. Their result is resolved like a field of that type, but without
+// the generated arguments like 'where', which would mix with the arguments of
+// the method. Other methods are skipped and reported to the logger, see
+// WithLogger. GraphQL executes the mutations of a request one after another,
+// but concurrent requests are not synchronized, which is up to the methods.
+// Results are never cached, see WithResultCache.
+func MutationStructViaGraphql[T any](rootField string, o *T, query string, opts ...Option) ([]byte, error) {
+	options := newOptions(opts)
+	options.mutations = true
+
+	result, err := queryStruct(rootField, o, query, options)
+	if err != nil {
+		return nil, err
+	}
+	return options.marshalResult(result)
+}
+
+// Creates the mutation root from the methods of o with a pointer receiver.
+func createMutationObject(o reflect.Value, typesMap map[string]Pair[graphql.Output, graphql.Fields], filterMap map[string]graphql.ArgumentConfig, options *options) (*graphql.Object, error) {
+	if o.Kind() != reflect.Pointer || o.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("mutations require a pointer to a struct, got %s", o.Type())
+	}
+	valueMethods := o.Elem().Type()
+
+	fields := graphql.Fields{}
+	for i := 0; i < o.NumMethod(); i++ {
+		method := o.Type().Method(i)
+
+		// Methods with a value receiver are fields of the query
+		if _, ok := valueMethods.MethodByName(method.Name); ok {
+			continue
+		}
+
+		// Like methods of the query, other methods are skipped, e.g. setters without a result
+		fn := o.Method(i)
+		signature, err := parseFuncSignature(fn.Type(), nil)
+		if err != nil {
+			options.logf("graphql: skipping mutation %s of %s: %v", method.Name, valueMethods.Name(), err)
+			continue
+		}
+
+		fieldName := strings.ToLower(method.Name)
+		returnType := fn.Type().Out(0)
+		output, _, err := createGraphQlFieldHierarchy(returnType, []string{fieldName}, typesMap, filterMap, options)
+		if err != nil {
+			return nil, err
+		}
+		if output == nil {
+			options.logf("graphql: skipping mutation %s of %s: result type %s is not supported", method.Name, valueMethods.Name(), returnType)
+			continue
+		}
+
+		// The arguments are those of the method, the result isn't filtered or paginated
+		args, err := signature.createArguments(options)
+		if err != nil {
+			return nil, fmt.Errorf("mutation %s of %s: %w", method.Name, valueMethods.Name(), err)
+		}
+
+		options.addReflectedField("RootMutation", fieldName, method.Type, methodField)
+		methodName := method.Name
+		fields[fieldName] = &graphql.Field{
+			Name: methodName,
+			Type: output,
+			Args: args,
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				r, err := signature.call(fn, p)
+				if err != nil {
+					return nil, err
+				}
+
+				p.Args = nil
+				return resolveFieldValue(r, p, methodName, options)
+			},
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%s has no methods with a pointer receiver to expose as mutations", valueMethods.Name())
+	}
+	return graphql.NewObject(graphql.ObjectConfig{Name: "RootMutation", Fields: fields}), nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// Executes the query like QueryStructViaGraphql, but writes the result as
+// newline-delimited JSON (NDJSON): Each element of the selected list is written
+// as a separate JSON object on its own line, so clients can process big
+// results line by line. The query must select exactly one root field that
+// resolves to a list. If the writer is an http.Flusher, every line is
+// flushed as soon as it is written.
+//
+// Only the output format differs from QueryStructViaGraphql: the query is
+// executed as a whole before the first line is written, so the first line
+// isn't sent any earlier and the whole result is held in memory.
+func QueryStructViaGraphqlNDJSON[T any](w io.Writer, rootField string, o T, query string, opts ...Option) error {
+	result, err := queryStruct(rootField, o, query, newOptions(opts))
+	if err != nil {
+		return err
+	}
+
+	var root any
+	switch data := result.Data.(type) {
+	case map[string]any:
+		if len(data) != 1 {
+			return errors.New("ndjson requires a query with a single root field")
+		}
+		for _, v := range data {
+			root = v
+		}
+	case orderedObject:
+		if len(data) != 1 {
+			return errors.New("ndjson requires a query with a single root field")
+		}
+		root = data[0].Value
+	}
+
+	list, ok := root.([]any)
+	if !ok {
+		return errors.New("ndjson requires the root field to be a list")
+	}
+
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+	for _, element := range list {
+		// Encode terminates each value with a newline
+		if err := enc.Encode(element); err != nil {
+			return err
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	return nil
+}
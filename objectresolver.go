@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Replaces every object of the struct type t by the result of fn before its
+// fields are resolved, e.g. to load the related data of an object up front
+// instead of once per field:
+//
+//	WithObjectResolver(reflect.TypeOf(Dog{}), func(source any) (any, error) {
+//		dog := source.(Dog)
+//		dog.Owner, err = loadOwner(dog.OwnerID)
+//		return dog, err
+//	})
+//
+// fn is called once per object and query with the struct value, and must
+// return a value of type t or a non-nil pointer to it. Its error fails all
+// selected fields of the object.
+func WithObjectResolver(t reflect.Type, fn func(source any) (any, error)) Option {
+	return func(o *options) {
+		if o.objectResolvers == nil {
+			o.objectResolvers = map[reflect.Type]func(source any) (any, error){}
+		}
+		o.objectResolvers[t] = fn
+	}
+}
+
+// The key of the objectSources of a query in its context.
+type objectSourcesKey struct{}
+
+// The results of the object resolvers of a query by the path of the object,
+// so the resolver runs once per object instead of once per field.
+type objectSources struct {
+	mutex   sync.Mutex
+	results map[string]objectSource
+}
+
+type objectSource struct {
+	value any
+	err   error
+}
+
+// Wraps the resolvers of the fields of t, so they receive
+// the result of the object resolver of t as their source.
+func wrapObjectResolver(t reflect.Type, fields graphql.Fields, fn func(source any) (any, error)) {
+	for _, field := range fields {
+		resolve := field.Resolve
+		field.Resolve = func(p graphql.ResolveParams) (any, error) {
+			source, err := resolveObjectSource(t, p, fn)
+			if err != nil {
+				return nil, err
+			}
+			p.Source = source
+			return resolve(p)
+		}
+	}
+}
+
+// Returns the result of the object resolver for the object of the field,
+// calling it only for the first field of the object that is resolved.
+func resolveObjectSource(t reflect.Type, p graphql.ResolveParams, fn func(source any) (any, error)) (any, error) {
+	sources, ok := p.Context.Value(objectSourcesKey{}).(*objectSources)
+	if !ok || p.Info.Path == nil {
+		// The schema is executed outside of executeQuery, e.g. after BuildSchema
+		return callObjectResolver(t, p.Source, fn)
+	}
+
+	key := fmt.Sprint(p.Info.Path.Prev.AsArray())
+
+	sources.mutex.Lock()
+	defer sources.mutex.Unlock()
+
+	result, ok := sources.results[key]
+	if !ok {
+		result.value, result.err = callObjectResolver(t, p.Source, fn)
+		sources.results[key] = result
+	}
+	return result.value, result.err
+}
+
+// Calls the object resolver and checks that it returned a value of type t.
+func callObjectResolver(t reflect.Type, source any, fn func(source any) (any, error)) (any, error) {
+	value, err := fn(source)
+	if err != nil {
+		return nil, err
+	}
+
+	r := reflect.ValueOf(value)
+	if r.Kind() == reflect.Pointer && r.Type().Elem() == t && !r.IsNil() {
+		r = r.Elem()
+	}
+	if !r.IsValid() || r.Type() != t {
+		return nil, fmt.Errorf("object resolver of %s returned %T instead of a %s", t.Name(), value, t.Name())
+	}
+	return r.Interface(), nil
+}
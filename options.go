@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Option configures how a struct is reflected into a GraphQL schema and
+// how query results are returned.
+type Option func(*options)
+
+type options struct {
+	// Marshal the result objects in the order the fields were selected
+	// in the query instead of the alphabetical order of encoding/json.
+	orderedFields bool
+
+	// The values of the variables of the query, see WithVariables
+	variables map[string]any
+
+	// The parent context of the query, see QueryStructViaGraphqlContext
+	ctx context.Context
+
+	// Expose the pointer receiver methods as mutations, see MutationStructViaGraphql
+	mutations bool
+
+	// Expose the channel fields as subscriptions, see SubscribeStructViaGraphql
+	subscriptions bool
+
+	missingKeyPolicy MissingKeyPolicy
+
+	// The representation of time.Time values, see WithTimeFormat
+	timeFormat TimeFormat
+
+	// Expose integers of up to 32 bits as Int, see WithSmallInts
+	smallInts bool
+
+	// Generate '<field>Count' fields for list fields, either
+	// for all types or only for the types in countFieldTypes.
+	countFields     bool
+	countFieldTypes map[reflect.Type]bool
+
+	nameCollisionPolicy NameCollisionPolicy
+
+	// Reject root fields named like a field of their type, see WithStrictRootField
+	strictRootField bool
+
+	// Named Merged types and the struct types they combine
+	mergedStructs map[reflect.Type][]reflect.Type
+
+	// Report deprecated fields used by a query in the result extensions
+	deprecationWarnings bool
+
+	// Report the selectivity of 'where' filters in the result extensions
+	filterStats bool
+
+	// Interface types and their member types, see WithUnion
+	unions map[reflect.Type][]reflect.Type
+
+	// The names of objects by their struct type, registered with
+	// WithTypeName or derived for anonymous structs, see objectName
+	typeNames map[reflect.Type]string
+
+	// The Go types by the GraphQL names of their objects, see claimTypeName
+	typeOwners map[string]reflect.Type
+
+	// The map types registered for *sync.Map fields, see WithSyncMap
+	syncMaps map[syncMapField]reflect.Type
+
+	// Names of the pagination arguments of lists, see WithPaginationArgs
+	skipArg  string
+	limitArg string
+
+	// Marshal results without indentation, see WithCompactJSON
+	compactJSON bool
+
+	// Resolve nil slices to null instead of an empty list, see WithNilSlicesAsNull
+	nilSlicesAsNull bool
+
+	// Add the 'sample' and 'seed' arguments to lists, see WithListSampling
+	listSampling bool
+
+	// Wrap all lists of structs in a page object, see WithPaginatedLists
+	paginatedLists bool
+
+	// Expose lists of structs as Relay connections, see WithConnections
+	connections bool
+
+	// Take the operators of 'where' filters as input objects, see WithFilterOperatorObjects
+	filterOperatorObjects bool
+
+	// Types exposed as custom scalars, see WithScalar
+	scalars map[reflect.Type]*graphql.Scalar
+
+	// Types exposed as enums, see WithEnumValues
+	enums map[reflect.Type]*enumType
+
+	// Values exposed by the '_service' root field, see WithServiceMetadata
+	serviceMetadata map[string]any
+
+	// Content types of the fields tagged as blob, collected while building the schema
+	blobs map[schemaField]string
+
+	// Serve repeated queries from a cache, see WithResultCache
+	resultCache *ResultCache
+
+	// The Go types and kinds of the fields, collected while building the schema
+	reflectedFields map[schemaField]reflectedField
+
+	// Called after every field is resolved, see WithResolveHook
+	resolveHook ResolveHook
+
+	// Return the resolution metadata of queries instead of their data, see WithExplain
+	explain bool
+
+	// Replace objects before their fields are resolved, see WithObjectResolver
+	objectResolvers map[reflect.Type]func(source any) (any, error)
+
+	// Recognize the types generated by protoc-gen-go, see WithProtobuf
+	protobuf bool
+
+	// Accessor methods of unexported fields, see WithAccessor
+	accessors map[accessorField]string
+
+	// Objects nested deeper are omitted from the schema, 0 means unlimited
+	maxBuildDepth int
+
+	// Queries nested deeper are rejected, 0 means unlimited
+	maxQueryDepth int
+
+	logger Logger
+}
+
+// Receives diagnostic messages, e.g. about fields omitted from the schema.
+// *log.Logger satisfies this interface.
+type Logger interface {
+	Printf(format string, v ...any)
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{skipArg: "skip", limitArg: "limit"}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Exposes int8, int16, int32, uint8 and uint16 values as Int instead of
+// Float, since they always fit into the 32 bits of GraphQL's Int. Their
+// JSON is integral then, e.g. 2 instead of 2.0 for clients that parse it
+// strictly. Other integers stay Float or Int64, see getBasicOutput.
+func WithSmallInts() Option {
+	return func(o *options) {
+		o.smallInts = true
+	}
+}
+
+// Keeps the fields of the marshaled result in the order they were
+// selected in the query. Without this option the fields are sorted
+// alphabetically since graphql-go returns the result data as maps.
+func WithOrderedFields() Option {
+	return func(o *options) {
+		o.orderedFields = true
+	}
+}
+
+// Passes the values of the variables declared by the query, e.g. as decoded
+// from the 'variables' of a JSON request body:
+//
+//	query := `query($color: String, $n: Int) { dogs(where: {color: $color}) { name tags(limit: $n) } }`
+//	QueryStructViaGraphql("dogs", dogs, query, WithVariables(map[string]any{"color": "Black", "n": 2}))
+//
+// The values are coerced to the types of the variables, missing
+// variables are null or take their default value.
+func WithVariables(variables map[string]any) Option {
+	return func(o *options) {
+		o.variables = variables
+	}
+}
+
+// Decides what a map field returns when its 'key' argument
+// refers to a key that doesn't exist in the map.
+type MissingKeyPolicy int
+
+const (
+	// Resolve the map field to null.
+	MissingKeyNull MissingKeyPolicy = iota
+	// Resolve the map field to a GraphQL error.
+	MissingKeyError
+)
+
+// Sets the behavior for map lookups of absent keys. Defaults to MissingKeyNull.
+func WithMissingKeyPolicy(policy MissingKeyPolicy) Option {
+	return func(o *options) {
+		o.missingKeyPolicy = policy
+	}
+}
+
+// Returns results as compact JSON without indentation, e.g. for production
+// APIs where the whitespace of the indented default only adds to the payload.
+func WithCompactJSON() Option {
+	return func(o *options) {
+		o.compactJSON = true
+	}
+}
+
+// Marshals a result, indented with two spaces unless WithCompactJSON is set.
+func (o *options) marshalResult(v any) ([]byte, error) {
+	if o.compactJSON {
+		return json.Marshal(v)
+	}
+	return json.MarshalIndent(v, "", "  ")
+}
+
+// Resolves nil slices to null like encoding/json encodes them, while
+// empty slices that aren't nil still resolve to an empty list. By default
+// both resolve to an empty list. Arrays, page objects and connections are
+// never null.
+func WithNilSlicesAsNull() Option {
+	return func(o *options) {
+		o.nilSlicesAsNull = true
+	}
+}
+
+// Adds a '<field>Count' field next to every list field that resolves to the
+// number of elements, after applying the 'where' filter if one is given:
+// dogs { name toysCount }
+// Without arguments the fields are added to all types, otherwise only
+// to the given struct types.
+func WithCountFields(types ...reflect.Type) Option {
+	return func(o *options) {
+		if len(types) == 0 {
+			o.countFields = true
+			return
+		}
+
+		if o.countFieldTypes == nil {
+			o.countFieldTypes = map[reflect.Type]bool{}
+		}
+		for _, t := range types {
+			o.countFieldTypes[t] = true
+		}
+	}
+}
+
+func (o *options) countFieldsEnabled(t reflect.Type) bool {
+	return o.countFields || o.countFieldTypes[t]
+}
+
+// Decides what happens when two Go fields or methods of a struct map to the
+// same GraphQL field name, e.g. 'ID' and 'Id' which are both lowercased to 'id'.
+type NameCollisionPolicy int
+
+const (
+	// Fail to build the schema.
+	NameCollisionError NameCollisionPolicy = iota
+	// Append a numeric suffix to the later fields in declaration order: 'id', 'id2', 'id3', ...
+	NameCollisionSuffix
+	// Keep the first field in declaration order and skip the later ones.
+	NameCollisionFirstWins
+)
+
+// Sets the behavior for colliding field names. Defaults to NameCollisionError.
+func WithNameCollisionPolicy(policy NameCollisionPolicy) Option {
+	return func(o *options) {
+		o.nameCollisionPolicy = policy
+	}
+}
+
+// Rejects a root field named like a field of its own type, e.g. 'friends'
+// for a list of cats with a Friends field. Such a schema is valid, but the
+// same name on two levels can be confusing in introspection and queries:
+// { friends { friends { name } } }
+func WithStrictRootField() Option {
+	return func(o *options) {
+		o.strictRootField = true
+	}
+}
+
+// Lists the deprecated fields selected by a query in the 'deprecations'
+// entry of the result extensions, so clients can log and migrate them.
+// Fields are deprecated with the `graphql:"deprecated=reason"` struct tag.
+func WithDeprecationWarnings() Option {
+	return func(o *options) {
+		o.deprecationWarnings = true
+	}
+}
+
+// Renames the arguments of list fields that skip and limit the elements,
+// e.g. to 'offset' and 'count' to match an existing API:
+// tags(offset: 1, count: 2)
+// Defaults to 'skip' and 'limit'.
+func WithPaginationArgs(skip, limit string) Option {
+	return func(o *options) {
+		o.skipArg = skip
+		o.limitArg = limit
+	}
+}
+
+// Sets the logger for diagnostic messages. Nothing is logged by default.
+func WithLogger(logger Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+func (o *options) logf(format string, v ...any) {
+	if o.logger != nil {
+		o.logger.Printf(format, v...)
+	}
+}
+
+// Limits how deep nested objects are reflected into the schema. Fields whose
+// object type would be nested more than depth fields below the root are omitted
+// and their paths are reported to the logger. This bounds the size and build
+// time of the schema for deep type graphs. Defaults to 0, meaning unlimited.
+func WithMaxBuildDepth(depth int) Option {
+	return func(o *options) {
+		o.maxBuildDepth = depth
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+// The argument of lists of structs that sorts the elements, see sortList.
+const orderByArg = "orderBy"
+
+var orderDirectionEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name: "OrderDirection",
+	Values: graphql.EnumValueConfigMap{
+		"ASC":  &graphql.EnumValueConfig{Value: "ASC"},
+		"DESC": &graphql.EnumValueConfig{Value: "DESC"},
+	},
+})
+
+// The 'orderBy' argument of lists of structs. The field is
+// given by its GraphQL name, the direction defaults to ASC:
+//
+//	dogs(orderBy: {field: "age", direction: DESC}) { name age }
+var orderByInput = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "OrderBy",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"field": &graphql.InputObjectFieldConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"direction": &graphql.InputObjectFieldConfig{
+			Type:         orderDirectionEnum,
+			DefaultValue: "ASC",
+		},
+	},
+})
+
+// Returns a sorted copy of the list of structs r if the 'orderBy' argument
+// is set. Numbers, strings and times can be sorted by, nil elements and nil
+// pointer fields are placed last. Elements with equal values keep their order.
+func sortList(r reflect.Value, p graphql.ResolveParams, options *options) (reflect.Value, error) {
+	orderBy, ok := p.Args[orderByArg].(map[string]any)
+	if !ok {
+		return r, nil
+	}
+	fieldName, _ := orderBy["field"].(string)
+	descending := orderBy["direction"] == "DESC"
+
+	elem := indirectType(r.Type().Elem())
+	field, ok := fieldByGraphqlName(elem, fieldName)
+	if !ok {
+		return r, fmt.Errorf("%s has no field %q to order by", elem.Name(), fieldName)
+	}
+	if !isSortable(indirectType(field.Type)) {
+		return r, fmt.Errorf("field %q of %s can't be ordered by", fieldName, elem.Name())
+	}
+
+	// The values are read up front, since accessors may be expensive
+	values := make([]reflect.Value, r.Len())
+	for i := range values {
+		element, ok := indirectValue(r.Index(i))
+		if !ok {
+			continue
+		}
+		value, err := options.fieldValue(element, field)
+		if err != nil {
+			return r, err
+		}
+		if value, ok := indirectValue(value); ok {
+			values[i] = value
+		}
+	}
+
+	indices := make([]int, r.Len())
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		x, y := values[indices[i]], values[indices[j]]
+		if !x.IsValid() || !y.IsValid() {
+			return x.IsValid()
+		}
+		if descending {
+			return lessSortable(y, x)
+		}
+		return lessSortable(x, y)
+	})
+
+	sorted := reflect.MakeSlice(reflect.SliceOf(r.Type().Elem()), r.Len(), r.Len())
+	for i, index := range indices {
+		sorted.Index(i).Set(r.Index(index))
+	}
+	return sorted, nil
+}
+
+// Returns true for the types lists can be ordered by.
+func isSortable(t reflect.Type) bool {
+	if t == typeTime {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String:
+		return true
+	}
+	return false
+}
+
+func lessSortable(x, y reflect.Value) bool {
+	if x.Type() == typeTime {
+		return x.Interface().(time.Time).Before(y.Interface().(time.Time))
+	}
+	return lessMapKey(x, y)
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+type orderedEntry struct {
+	Key   string
+	Value any
+}
+
+// A JSON object that keeps the order of its entries when marshaled,
+// unlike map[string]any which encoding/json sorts alphabetically.
+type orderedObject []orderedEntry
+
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, e := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, err := json.Marshal(e.Key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+
+		value, err := json.Marshal(e.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Parses the query and returns its first operation together with all
+// fragment definitions by name. The operation is nil if there is none.
+func parseOperation(query string) (*ast.OperationDefinition, map[string]*ast.FragmentDefinition, error) {
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fragments := map[string]*ast.FragmentDefinition{}
+	var operation *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.FragmentDefinition:
+			fragments[def.Name.Value] = def
+		case *ast.OperationDefinition:
+			if operation == nil {
+				operation = def
+			}
+		}
+	}
+	return operation, fragments, nil
+}
+
+// Identifies a field of the schema by the name of the
+// GraphQL object declaring it and its GraphQL field name.
+type schemaField struct {
+	typeName  string
+	fieldName string
+}
+
+// Walks through the selections of the query alongside the schema types and
+// calls visit for every selected field with the type declaring it and its name
+// in the schema. Fragments are expanded in place, fields that don't exist in
+// the schema like '__typename' are skipped.
+func walkSelectedFields(schema graphql.Schema, parent graphql.Type, set *ast.SelectionSet, fragments map[string]*ast.FragmentDefinition, visit func(parent graphql.Type, name string, field *graphql.FieldDefinition)) {
+	if set == nil {
+		return
+	}
+
+	var fields graphql.FieldDefinitionMap
+	switch parent := parent.(type) {
+	case *graphql.Object:
+		fields = parent.Fields()
+	case *graphql.Interface:
+		fields = parent.Fields()
+	}
+
+	for _, selection := range set.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			field, ok := fields[selection.Name.Value]
+			if !ok {
+				continue
+			}
+
+			visit(parent, selection.Name.Value, field)
+			walkSelectedFields(schema, graphql.GetNamed(field.Type).(graphql.Type), selection.SelectionSet, fragments, visit)
+		case *ast.InlineFragment:
+			t := parent
+			if selection.TypeCondition != nil {
+				t = schema.Type(selection.TypeCondition.Name.Value)
+			}
+			walkSelectedFields(schema, t, selection.SelectionSet, fragments, visit)
+		case *ast.FragmentSpread:
+			fragment, ok := fragments[selection.Name.Value]
+			if !ok {
+				continue
+			}
+			walkSelectedFields(schema, schema.Type(fragment.TypeCondition.Name.Value), fragment.SelectionSet, fragments, visit)
+		}
+	}
+}
+
+// Rearranges the result data of a query so that every object lists its
+// fields in the order they were selected in the query.
+func orderResultData(query string, data any) (any, error) {
+	operation, fragments, err := parseOperation(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if operation == nil {
+		return data, nil
+	}
+
+	return orderValue(data, []*ast.SelectionSet{operation.SelectionSet}, fragments), nil
+}
+
+// Collects the response keys of the given selection sets in selection order.
+// Fragments are expanded in place. A key that is selected several times keeps
+// its first position and merges the sub-selections of all occurrences.
+func collectResponseKeys(sets []*ast.SelectionSet, fragments map[string]*ast.FragmentDefinition, keys []string, children map[string][]*ast.SelectionSet) []string {
+	for _, set := range sets {
+		if set == nil {
+			continue
+		}
+
+		for _, selection := range set.Selections {
+			switch selection := selection.(type) {
+			case *ast.Field:
+				key := selection.Name.Value
+				if selection.Alias != nil {
+					key = selection.Alias.Value
+				}
+
+				if _, ok := children[key]; !ok {
+					keys = append(keys, key)
+					children[key] = nil
+				}
+				if selection.SelectionSet != nil {
+					children[key] = append(children[key], selection.SelectionSet)
+				}
+			case *ast.InlineFragment:
+				keys = collectResponseKeys([]*ast.SelectionSet{selection.SelectionSet}, fragments, keys, children)
+			case *ast.FragmentSpread:
+				if fragment, ok := fragments[selection.Name.Value]; ok {
+					keys = collectResponseKeys([]*ast.SelectionSet{fragment.SelectionSet}, fragments, keys, children)
+				}
+			}
+		}
+	}
+	return keys
+}
+
+func orderValue(value any, sets []*ast.SelectionSet, fragments map[string]*ast.FragmentDefinition) any {
+	switch v := value.(type) {
+	case map[string]any:
+		children := map[string][]*ast.SelectionSet{}
+		keys := collectResponseKeys(sets, fragments, nil, children)
+
+		o := make(orderedObject, 0, len(v))
+		for _, key := range keys {
+			if field, ok := v[key]; ok {
+				o = append(o, orderedEntry{Key: key, Value: orderValue(field, children[key], fragments)})
+			}
+		}
+
+		// Keys that are not part of the query shouldn't exist,
+		// but make sure no data is lost if they do.
+		if len(o) < len(v) {
+			var rest []string
+			for key := range v {
+				if _, ok := children[key]; !ok {
+					rest = append(rest, key)
+				}
+			}
+			sort.Strings(rest)
+			for _, key := range rest {
+				o = append(o, orderedEntry{Key: key, Value: v[key]})
+			}
+		}
+		return o
+	case []any:
+		list := make([]any, len(v))
+		for i, element := range v {
+			list[i] = orderValue(element, sets, fragments)
+		}
+		return list
+	default:
+		return value
+	}
+}
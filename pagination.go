@@ -0,0 +1,150 @@
+package main
+
+import (
+	"reflect"
+
+	"github.com/graphql-go/graphql"
+)
+
+// The value of a paginated list field, see WithPaginatedLists.
+type listPage struct {
+	Items   any
+	Total   int
+	HasMore bool
+}
+
+// Wraps every list of structs in a page object with the selected window of
+// elements, the number of elements matching the filter and whether there are
+// more elements after the window:
+//
+//	dogs(where: {color: "Black"}, skip: 10, limit: 10) { items { name } total hasMore }
+//
+// Single fields can be paginated with the `graphql:"paginated"` tag instead.
+func WithPaginatedLists() Option {
+	return func(o *options) {
+		o.paginatedLists = true
+	}
+}
+
+// Returns true if a field of type t is exposed as a page object.
+func (o *options) paginated(t reflect.Type, tag fieldTag) bool {
+	if !o.paginatedLists && !tag.has("paginated") {
+		return false
+	}
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && indirectType(t.Elem()).Kind() == reflect.Struct
+}
+
+// Creates the page object for lists with the given element type.
+func createPageObject(t reflect.Type, list graphql.Output, typesMap map[string]Pair[graphql.Output, graphql.Fields], options *options) graphql.Output {
+	name := options.typeNamePart(t.Elem()) + "Page"
+	if knownType, ok := typesMap[name]; ok {
+		return knownType.First
+	}
+
+	fields := graphql.Fields{
+		"items": &graphql.Field{
+			Name: "Items",
+			Type: list,
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source.(listPage).Items, nil
+			},
+		},
+		"total": &graphql.Field{
+			Name: "Total",
+			Type: graphql.Int,
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source.(listPage).Total, nil
+			},
+		},
+		"hasMore": &graphql.Field{
+			Name: "HasMore",
+			Type: graphql.Boolean,
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source.(listPage).HasMore, nil
+			},
+		},
+	}
+
+	o := graphql.NewObject(graphql.ObjectConfig{
+		Name:   name,
+		Fields: fields,
+	})
+	typesMap[name] = Pair[graphql.Output, graphql.Fields]{First: o, Second: fields}
+	return o
+}
+
+// Creates the arguments of a paginated list, which are the 'where' filter
+// of the elements and the pagination arguments.
+func createPageArguments(fieldName string, t reflect.Type, filterMap map[string]graphql.ArgumentConfig, options *options) (graphql.FieldConfigArgument, error) {
+	where, err := createFilterArgument(fieldName, indirectType(t.Elem()), filterMap, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return graphql.FieldConfigArgument{
+		"where":          where,
+		orderByArg:       &graphql.ArgumentConfig{Type: orderByInput},
+		distinctArg:      &graphql.ArgumentConfig{Type: graphql.String},
+		options.skipArg:  &graphql.ArgumentConfig{Type: graphql.Int},
+		options.limitArg: &graphql.ArgumentConfig{Type: graphql.Int},
+	}, nil
+}
+
+// Filters the list with the 'where' argument, sorts it by the 'orderBy'
+// argument and returns the page selected by the pagination arguments.
+func paginateList(r reflect.Value, p graphql.ResolveParams, options *options) (any, error) {
+	for r.Kind() == reflect.Pointer || r.Kind() == reflect.Interface {
+		if r.IsNil() {
+			return nil, nil
+		}
+		r = r.Elem()
+	}
+
+	matches, err := matchingElements(r, p, options)
+	if err != nil {
+		return nil, err
+	}
+
+	total := matches.Len()
+	start, end := 0, total
+	if skip, ok := p.Args[options.skipArg].(int); ok {
+		start = max(0, min(skip, total))
+	}
+	if limit, ok := p.Args[options.limitArg].(int); ok {
+		end = start + max(0, min(limit, total-start))
+	}
+
+	return listPage{
+		Items:   matches.Slice(start, end).Interface(),
+		Total:   total,
+		HasMore: end < total,
+	}, nil
+}
+
+// Returns a slice of the elements of the list r that match the 'where'
+// argument, sorted by the 'orderBy' argument and without the duplicates
+// removed by the 'distinct' argument.
+func matchingElements(r reflect.Value, p graphql.ResolveParams, options *options) (reflect.Value, error) {
+	matches := reflect.MakeSlice(reflect.SliceOf(r.Type().Elem()), 0, r.Len())
+	filter, filterSet := p.Args["where"].(map[string]any)
+	if filterSet {
+		if err := checkFilterValues("where", r.Type().Elem(), filter, options); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	for i := 0; i < r.Len(); i++ {
+		if !filterSet || matchesFilter(r.Index(i), filter, options) {
+			matches = reflect.Append(matches, r.Index(i))
+		}
+	}
+
+	if stats := filterStatsFrom(p.Context); stats != nil && filterSet {
+		stats.record(p, matches.Len(), r.Len())
+	}
+
+	sorted, err := sortList(matches, p, options)
+	if err != nil {
+		return sorted, err
+	}
+	return distinctList(sorted, p, options)
+}
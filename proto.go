@@ -0,0 +1,108 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+// The package of the well-known Timestamp message of protobuf.
+const protoTimestampPackage = "google.golang.org/protobuf/types/known/timestamppb"
+
+// A *timestamppb.Timestamp, serialized as milliseconds since
+// the Unix epoch like time.Time fields, see WithProtobuf.
+var protoTimestampScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "Timestamp",
+	Description: "The `Timestamp` scalar type represents a protobuf timestamp as milliseconds since the Unix epoch.",
+	Serialize: func(value any) any {
+		r := reflect.ValueOf(value)
+		if r.Kind() == reflect.Pointer {
+			if r.IsNil() {
+				return nil
+			}
+			r = r.Elem()
+		}
+		if !isProtoTimestamp(r.Type()) {
+			return nil
+		}
+		return float64(protoTimestamp(r).UnixMilli())
+	},
+})
+
+// Makes structs generated by protoc-gen-go reflect cleanly, so gRPC
+// messages can be exposed without writing GraphQL types for them:
+//
+//   - The internal fields 'state', 'sizeCache' and 'unknownFields' are
+//     unexported and skipped like all unexported fields. The exported
+//     'XXX_' fields of the older generator are skipped as well.
+//   - Proto enums are exposed as GraphQL enums with the value names
+//     of their descriptor, e.g. 'COLOR_RED'.
+//   - *timestamppb.Timestamp fields are exposed as milliseconds since
+//     the Unix epoch like time.Time fields.
+//
+// The getters of generated messages have pointer receivers and are not exposed
+// as fields. The package doesn't depend on protobuf, the generated types are
+// recognized by their methods and package paths.
+func WithProtobuf() Option {
+	return func(o *options) {
+		o.protobuf = true
+	}
+}
+
+// Returns true for the internal fields of messages of the older protobuf generator.
+func (o *options) skipProtoField(field reflect.StructField) bool {
+	return o.protobuf && strings.HasPrefix(field.Name, "XXX_")
+}
+
+func isProtoTimestamp(t reflect.Type) bool {
+	return t.Name() == "Timestamp" && t.PkgPath() == protoTimestampPackage
+}
+
+// Returns the time of a timestamppb.Timestamp value. The fields are read
+// directly, since AsTime has a pointer receiver.
+func protoTimestamp(r reflect.Value) time.Time {
+	return time.Unix(r.FieldByName("Seconds").Int(), r.FieldByName("Nanos").Int())
+}
+
+// Returns true for protobuf enums, which are named int32 types whose
+// Descriptor method returns a protoreflect.EnumDescriptor.
+func isProtoEnum(t reflect.Type) bool {
+	if t.Kind() != reflect.Int32 || t.Name() == "" {
+		return false
+	}
+	descriptor, ok := t.MethodByName("Descriptor")
+	if !ok || descriptor.Type.NumIn() != 1 || descriptor.Type.NumOut() != 1 {
+		return false
+	}
+	_, ok = descriptor.Type.Out(0).MethodByName("Values")
+	return ok
+}
+
+// Returns the numbers of the values declared in the descriptor of a protobuf enum.
+// The descriptor is read by reflection to avoid depending on protoreflect:
+//
+//	values := Color(0).Descriptor().Values()
+//	for i := 0; i < values.Len(); i++ {
+//		values.Get(i).Number()
+//	}
+func protoEnumValues(t reflect.Type) []any {
+	descriptor := reflect.Zero(t).MethodByName("Descriptor").Call(nil)[0]
+	values := descriptor.MethodByName("Values").Call(nil)[0]
+
+	var numbers []any
+	seen := map[int64]bool{}
+	n := int(values.MethodByName("Len").Call(nil)[0].Int())
+	for i := 0; i < n; i++ {
+		value := values.MethodByName("Get").Call([]reflect.Value{reflect.ValueOf(i)})[0]
+		number := value.MethodByName("Number").Call(nil)[0].Int()
+
+		// Aliases share the number and the name of the first value, see 'allow_alias'
+		if !seen[number] {
+			numbers = append(numbers, number)
+			seen[number] = true
+		}
+	}
+	return numbers
+}
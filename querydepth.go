@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// Rejects queries whose selections are nested more than depth fields deep
+// before they are executed, e.g. deeply nested queries of recursive types:
+//
+//	{ categories { children { children { name } } } }
+//
+// The fields of the root field are at depth 2, 'name' above at depth 4.
+// Fragments count as if their selections were written in place. Defaults
+// to 0, meaning unlimited.
+func WithMaxQueryDepth(depth int) Option {
+	return func(o *options) {
+		o.maxQueryDepth = depth
+	}
+}
+
+// Returns an error if an operation of the query is nested deeper than the
+// max query depth. Queries that can't be parsed are left to the execution,
+// which reports the syntax error.
+func checkQueryDepth(query string, options *options) error {
+	if options.maxQueryDepth <= 0 {
+		return nil
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return nil
+	}
+
+	fragments := map[string]*ast.FragmentDefinition{}
+	for _, def := range doc.Definitions {
+		if fragment, ok := def.(*ast.FragmentDefinition); ok {
+			fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	for _, def := range doc.Definitions {
+		operation, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if depth := selectionDepth(operation.SelectionSet, fragments, map[string]int{}); depth > options.maxQueryDepth {
+			return fmt.Errorf("query is nested %d fields deep, exceeding the max depth of %d", depth, options.maxQueryDepth)
+		}
+	}
+	return nil
+}
+
+// Returns how many fields deep the selection set is nested. The depths of
+// fragments are remembered, so spreading a fragment many times doesn't walk it
+// again. Fragments that spread themselves are invalid and rejected by the
+// validation, they count as -1 while they are walked to terminate.
+func selectionDepth(set *ast.SelectionSet, fragments map[string]*ast.FragmentDefinition, fragmentDepths map[string]int) int {
+	if set == nil {
+		return 0
+	}
+
+	depth := 0
+	for _, selection := range set.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			depth = max(depth, 1+selectionDepth(selection.SelectionSet, fragments, fragmentDepths))
+		case *ast.InlineFragment:
+			depth = max(depth, selectionDepth(selection.SelectionSet, fragments, fragmentDepths))
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			fragmentDepth, ok := fragmentDepths[name]
+			if !ok {
+				fragment, ok := fragments[name]
+				if !ok {
+					continue
+				}
+
+				fragmentDepths[name] = -1
+				fragmentDepth = selectionDepth(fragment.SelectionSet, fragments, fragmentDepths)
+				fragmentDepths[name] = fragmentDepth
+			}
+			depth = max(depth, fragmentDepth)
+		}
+	}
+	return depth
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// The suffix of filter fields that match string fields against a regular expression:
+// cats(where: {name_regex: "^Ma"}) { name }
+const regexFilterSuffix = "_regex"
+
+// Patterns are bounded in length to bound the time to compile and match them.
+// Go's regexp package guarantees linear time matching, so there is no
+// catastrophic backtracking, but the time still grows with the pattern.
+const maxRegexLength = 256
+
+// Compiled patterns are cached, since graphql-go parses filter values once
+// during validation and once during execution. The cache is cleared once it
+// holds maxCachedRegexes patterns.
+const maxCachedRegexes = 1000
+
+var (
+	regexCacheMutex sync.Mutex
+	regexCache      = map[string]*regexp.Regexp{}
+)
+
+// A regular expression in the RE2 syntax, parsed into a *regexp.Regexp.
+// Invalid or too long patterns fail the filter with the reason, see
+// regexError. Fields of type regexp.Regexp and *regexp.Regexp are
+// serialized as their pattern.
+var regexScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "Regex",
+	Description: "The `Regex` scalar type represents a regular expression in the RE2 syntax of at most " + strconv.Itoa(maxRegexLength) + " characters.",
+	Serialize: func(value any) any {
+		switch re := value.(type) {
+		case *regexp.Regexp:
+			if re != nil {
+				return re.String()
+			}
+		case regexp.Regexp:
+			// Fields of type *regexp.Regexp are dereferenced, see resolveFieldValue
+			return re.String()
+		}
+		return nil
+	},
+	ParseValue: func(value any) any {
+		s, ok := value.(string)
+		if !ok {
+			return nil
+		}
+		return compileRegex(s)
+	},
+	ParseLiteral: func(valueAST ast.Value) any {
+		s, ok := valueAST.(*ast.StringValue)
+		if !ok {
+			return nil
+		}
+		return compileRegex(s.Value)
+	},
+})
+
+// A pattern that can't be used as a Regex value. The scalar accepts it, since
+// graphql-go would only report `Expected type "Regex", found "(("`, and the
+// filter fails with this error instead, see checkFilterValues.
+type regexError struct {
+	pattern string
+	err     error
+}
+
+func (e *regexError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("regular expression of %d characters is longer than the maximum of %d", len(e.pattern), maxRegexLength)
+	}
+	return e.err.Error()
+}
+
+// Returns the compiled pattern, or a *regexError if it is invalid or too long.
+func compileRegex(pattern string) any {
+	if len(pattern) > maxRegexLength {
+		return &regexError{pattern: pattern}
+	}
+
+	regexCacheMutex.Lock()
+	defer regexCacheMutex.Unlock()
+
+	if re, ok := regexCache[pattern]; ok {
+		return re
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return &regexError{pattern: pattern, err: err}
+	}
+
+	if len(regexCache) >= maxCachedRegexes {
+		regexCache = map[string]*regexp.Regexp{}
+	}
+	regexCache[pattern] = re
+	return re
+}
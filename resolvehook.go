@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Called after a field is resolved with the field's schema coordinate, e.g.
+// "Dog.name", the time its resolver took and the error it returned.
+type ResolveHook func(field string, duration time.Duration, err error)
+
+// Calls hook after every field of the schema is resolved, e.g. to record
+// metrics or traces per field:
+//
+//	WithResolveHook(func(field string, d time.Duration, err error) {
+//		resolveSeconds.WithLabelValues(field).Observe(d.Seconds())
+//	})
+//
+// The hook is called for every element of a list, so it should be cheap.
+// Lists of scalars are resolved as a whole. The duration of an object field
+// doesn't include the resolution of its subfields, which are resolved
+// afterwards. The resolvers are only wrapped if a hook is set.
+func WithResolveHook(hook ResolveHook) Option {
+	return func(o *options) {
+		o.resolveHook = hook
+	}
+}
+
+// Wraps the resolvers of all object fields of the schema to call the hook.
+// The introspection types are left out. The objects must not be shared with
+// other schemas, e.g. as package variables, or their resolvers would call
+// the hooks of all of them.
+func hookResolvers(schema graphql.Schema, hook ResolveHook) {
+	for name, t := range schema.TypeMap() {
+		object, ok := t.(*graphql.Object)
+		if !ok || strings.HasPrefix(name, "__") {
+			continue
+		}
+
+		for fieldName, field := range object.Fields() {
+			resolve := field.Resolve
+			if resolve == nil {
+				resolve = graphql.DefaultResolveFn
+			}
+
+			coordinate := name + "." + fieldName
+			field.Resolve = func(p graphql.ResolveParams) (any, error) {
+				start := time.Now()
+				value, err := resolve(p)
+				hook(coordinate, time.Since(start), err)
+				return value, err
+			}
+		}
+	}
+}
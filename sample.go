@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+
+	"github.com/graphql-go/graphql"
+)
+
+// The arguments of lists that select a random sample, see WithListSampling.
+const (
+	sampleArg = "sample"
+	seedArg   = "seed"
+)
+
+// Adds a 'sample' argument to all lists that selects the given number of
+// random elements, e.g. for previews:
+//
+//	cats(sample: 2) { name }
+//
+// The sample keeps the order of the list and is taken after the 'where'
+// filter, but before 'skip' and 'limit'. The optional 'seed' argument makes
+// the sample reproducible, without it every query gets a different one.
+// Paginated lists don't accept these arguments, see WithPaginatedLists.
+func WithListSampling() Option {
+	return func(o *options) {
+		o.listSampling = true
+	}
+}
+
+// Adds the sampling arguments to the arguments of a list.
+func addSampleArguments(args graphql.FieldConfigArgument) {
+	args[sampleArg] = &graphql.ArgumentConfig{Type: graphql.Int}
+	args[seedArg] = &graphql.ArgumentConfig{Type: graphql.Int}
+}
+
+// Returns a random sample of n elements of the list or array r as a new slice,
+// or r itself if it doesn't have more than n elements.
+func sampleList(r reflect.Value, n int, args map[string]any) (reflect.Value, error) {
+	if n < 0 {
+		return reflect.Value{}, fmt.Errorf("%s must not be negative", sampleArg)
+	}
+	if r.Len() <= n {
+		return r, nil
+	}
+
+	intn := rand.Intn
+	if seed, ok := args[seedArg].(int); ok {
+		intn = rand.New(rand.NewSource(int64(seed))).Intn
+	}
+
+	// Reservoir sampling picks the indices in a single pass without copying or
+	// shuffling the list. Every element ends up in the sample with probability n/len.
+	indices := make([]int, n)
+	for i := range indices {
+		indices[i] = i
+	}
+	for i := n; i < r.Len(); i++ {
+		if k := intn(i + 1); k < n {
+			indices[k] = i
+		}
+	}
+	sort.Ints(indices)
+
+	sample := reflect.MakeSlice(reflect.SliceOf(r.Type().Elem()), n, n)
+	for i, index := range indices {
+		sample.Index(i).Set(r.Index(index))
+	}
+	return sample, nil
+}
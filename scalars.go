@@ -0,0 +1,142 @@
+package main
+
+import (
+	"reflect"
+	"unicode/utf8"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// A single unicode character, used for fields tagged with `graphql:"rune"`.
+var runeScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "Rune",
+	Description: "The `Rune` scalar type represents a single unicode character.",
+	Serialize: func(value any) any {
+		r := reflect.ValueOf(value)
+		if r.CanInt() {
+			return string(rune(r.Int()))
+		}
+		return nil
+	},
+	ParseValue: func(value any) any {
+		s, ok := value.(string)
+		if !ok {
+			return nil
+		}
+		return parseRune(s)
+	},
+	ParseLiteral: func(valueAST ast.Value) any {
+		s, ok := valueAST.(*ast.StringValue)
+		if !ok {
+			return nil
+		}
+		return parseRune(s.Value)
+	},
+})
+
+// Returns the rune of a string consisting of exactly one character, nil otherwise.
+func parseRune(s string) any {
+	if utf8.RuneCountInString(s) != 1 {
+		return nil
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	return r
+}
+
+// A boolean serialized as 0 or 1, used for fields tagged with `graphql:"bool=int"`.
+var boolIntScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "BoolInt",
+	Description: "The `BoolInt` scalar type represents `true` or `false` as `1` or `0`.",
+	Serialize: func(value any) any {
+		if b, ok := value.(bool); ok {
+			if b {
+				return 1
+			}
+			return 0
+		}
+		return nil
+	},
+	ParseValue: func(value any) any {
+		switch value {
+		case 0, 0.0:
+			return false
+		case 1, 1.0:
+			return true
+		}
+		return nil
+	},
+	ParseLiteral: func(valueAST ast.Value) any {
+		if i, ok := valueAST.(*ast.IntValue); ok {
+			return parseBool(i.Value, "1", "0")
+		}
+		return nil
+	},
+})
+
+// A boolean serialized as "yes" or "no", used for fields tagged with `graphql:"bool=yesno"`.
+var boolYesNoScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "BoolYesNo",
+	Description: "The `BoolYesNo` scalar type represents `true` or `false` as `\"yes\"` or `\"no\"`.",
+	Serialize: func(value any) any {
+		if b, ok := value.(bool); ok {
+			if b {
+				return "yes"
+			}
+			return "no"
+		}
+		return nil
+	},
+	ParseValue: func(value any) any {
+		s, ok := value.(string)
+		if !ok {
+			return nil
+		}
+		return parseBool(s, "yes", "no")
+	},
+	ParseLiteral: func(valueAST ast.Value) any {
+		if s, ok := valueAST.(*ast.StringValue); ok {
+			return parseBool(s.Value, "yes", "no")
+		}
+		return nil
+	},
+})
+
+// Returns true or false if s is the representation of one of them, nil otherwise.
+func parseBool(s, t, f string) any {
+	switch s {
+	case t:
+		return true
+	case f:
+		return false
+	}
+	return nil
+}
+
+// Exposes the type t as the given scalar instead of deriving its GraphQL type,
+// e.g. to serialize a small struct as a compact string:
+//
+//	var pointScalar = graphql.NewScalar(graphql.ScalarConfig{
+//		Name: "Point",
+//		Serialize: func(value any) any {
+//			p := value.(image.Point)
+//			return fmt.Sprintf("%d,%d", p.X, p.Y)
+//		},
+//		ParseValue:   ...,
+//		ParseLiteral: ...,
+//	})
+//
+//	WithScalar(reflect.TypeOf(image.Point{}), pointScalar)
+//
+// Serialize receives the Go value of type t. The values returned by ParseValue
+// and ParseLiteral are compared with the field values in 'where' filters, so
+// they should be of type t as well. Structs registered as scalar are not
+// exposed as objects.
+func WithScalar(t reflect.Type, scalar *graphql.Scalar) Option {
+	return func(o *options) {
+		if o.scalars == nil {
+			o.scalars = map[reflect.Type]*graphql.Scalar{}
+		}
+		o.scalars[t] = scalar
+	}
+}
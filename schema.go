@@ -0,0 +1,157 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+// A structured description of the types of a schema for external tooling,
+// e.g. schema explorers, so they don't have to parse the SDL. Fields refer
+// to other types by name, which describes the relationships between them.
+type SchemaSummary struct {
+	QueryType    string          `json:"queryType"`
+	Objects      []ObjectSummary `json:"objects"`
+	InputObjects []ObjectSummary `json:"inputObjects"`
+	Unions       []UnionSummary  `json:"unions"`
+	Enums        []EnumSummary   `json:"enums"`
+	Scalars      []ScalarSummary `json:"scalars"`
+}
+
+// An object or input object and its fields, sorted by name.
+type ObjectSummary struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Fields      []FieldSummary `json:"fields"`
+}
+
+// A field of an object. Type is the full type reference like "[Dog]!", while
+// TypeName is the name of the referenced type like "Dog".
+type FieldSummary struct {
+	Name              string            `json:"name"`
+	Type              string            `json:"type"`
+	TypeName          string            `json:"typeName"`
+	Description       string            `json:"description,omitempty"`
+	Args              []ArgumentSummary `json:"args,omitempty"`
+	DeprecationReason string            `json:"deprecationReason,omitempty"`
+}
+
+// An argument of a field, see FieldSummary for the type references.
+type ArgumentSummary struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	TypeName    string `json:"typeName"`
+	Description string `json:"description,omitempty"`
+}
+
+type UnionSummary struct {
+	Name    string   `json:"name"`
+	Members []string `json:"members"`
+}
+
+type EnumSummary struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+type ScalarSummary struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Builds the schema that QueryStructViaGraphql executes queries against,
+// e.g. to serve it to tools or to inspect it with SummarizeSchema.
+func BuildSchema[T any](rootField string, o T, opts ...Option) (graphql.Schema, error) {
+	return buildSchema(rootField, o, newOptions(opts))
+}
+
+// Describes the types of the schema, see SchemaSummary. The introspection
+// types are omitted, all lists are sorted by name.
+func SummarizeSchema(schema graphql.Schema) SchemaSummary {
+	summary := SchemaSummary{
+		Objects:      []ObjectSummary{},
+		InputObjects: []ObjectSummary{},
+		Unions:       []UnionSummary{},
+		Enums:        []EnumSummary{},
+		Scalars:      []ScalarSummary{},
+	}
+	if query := schema.QueryType(); query != nil {
+		summary.QueryType = query.Name()
+	}
+
+	for name, t := range schema.TypeMap() {
+		if strings.HasPrefix(name, "__") {
+			continue
+		}
+
+		switch t := t.(type) {
+		case *graphql.Object:
+			object := ObjectSummary{Name: name, Description: t.Description(), Fields: []FieldSummary{}}
+			for _, field := range t.Fields() {
+				object.Fields = append(object.Fields, summarizeField(field))
+			}
+			sort.Slice(object.Fields, func(i, j int) bool { return object.Fields[i].Name < object.Fields[j].Name })
+			summary.Objects = append(summary.Objects, object)
+
+		case *graphql.InputObject:
+			object := ObjectSummary{Name: name, Description: t.Description(), Fields: []FieldSummary{}}
+			for _, field := range t.Fields() {
+				object.Fields = append(object.Fields, FieldSummary{
+					Name:        field.Name(),
+					Type:        field.Type.String(),
+					TypeName:    graphql.GetNamed(field.Type).String(),
+					Description: field.Description(),
+				})
+			}
+			sort.Slice(object.Fields, func(i, j int) bool { return object.Fields[i].Name < object.Fields[j].Name })
+			summary.InputObjects = append(summary.InputObjects, object)
+
+		case *graphql.Union:
+			union := UnionSummary{Name: name, Members: []string{}}
+			for _, member := range t.Types() {
+				union.Members = append(union.Members, member.Name())
+			}
+			sort.Strings(union.Members)
+			summary.Unions = append(summary.Unions, union)
+
+		case *graphql.Enum:
+			enum := EnumSummary{Name: name, Values: []string{}}
+			for _, value := range t.Values() {
+				enum.Values = append(enum.Values, value.Name)
+			}
+			sort.Strings(enum.Values)
+			summary.Enums = append(summary.Enums, enum)
+
+		case *graphql.Scalar:
+			summary.Scalars = append(summary.Scalars, ScalarSummary{Name: name, Description: t.Description()})
+		}
+	}
+
+	sort.Slice(summary.Objects, func(i, j int) bool { return summary.Objects[i].Name < summary.Objects[j].Name })
+	sort.Slice(summary.InputObjects, func(i, j int) bool { return summary.InputObjects[i].Name < summary.InputObjects[j].Name })
+	sort.Slice(summary.Unions, func(i, j int) bool { return summary.Unions[i].Name < summary.Unions[j].Name })
+	sort.Slice(summary.Enums, func(i, j int) bool { return summary.Enums[i].Name < summary.Enums[j].Name })
+	sort.Slice(summary.Scalars, func(i, j int) bool { return summary.Scalars[i].Name < summary.Scalars[j].Name })
+	return summary
+}
+
+func summarizeField(field *graphql.FieldDefinition) FieldSummary {
+	summary := FieldSummary{
+		Name:              field.Name,
+		Type:              field.Type.String(),
+		TypeName:          graphql.GetNamed(field.Type).String(),
+		Description:       field.Description,
+		DeprecationReason: field.DeprecationReason,
+	}
+	for _, arg := range field.Args {
+		summary.Args = append(summary.Args, ArgumentSummary{
+			Name:        arg.Name(),
+			Type:        arg.Type.String(),
+			TypeName:    graphql.GetNamed(arg.Type).String(),
+			Description: arg.Description(),
+		})
+	}
+	sort.Slice(summary.Args, func(i, j int) bool { return summary.Args[i].Name < summary.Args[j].Name })
+	return summary
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Builds schemas once and executes many queries against them, instead of
+// reflecting the struct again for every query like QueryStructViaGraphql:
+//
+//	builder := NewSchemaBuilder(WithTimeFormat(TimeRFC3339), WithSmallInts())
+//	schema, err := builder.Build("dogs", dogs)
+//	...
+//	b, err := builder.Execute(schema, query, variables)
+//
+// The builder holds the options of all schemas it builds. The data is read
+// when a query is executed, so the schema reflects changes of the values,
+// but not of their types. A builder can execute queries concurrently.
+type SchemaBuilder struct {
+	opts []Option
+
+	mu sync.Mutex
+	// The options of the built schemas by their query type,
+	// which hold the metadata collected while building them
+	schemas map[*graphql.Object]builtSchema
+}
+
+type builtSchema struct {
+	rootField string
+	options   *options
+}
+
+// Creates a builder whose schemas and queries use the given options.
+func NewSchemaBuilder(opts ...Option) *SchemaBuilder {
+	return &SchemaBuilder{opts: opts, schemas: map[*graphql.Object]builtSchema{}}
+}
+
+// Builds the schema that exposes o as the root field like BuildSchema.
+// Methods can't have type parameters, so o is taken as any and
+// reflected by its dynamic type, e.g. []Dog for a slice of dogs.
+func (b *SchemaBuilder) Build(rootField string, o any) (graphql.Schema, error) {
+	options := newOptions(b.opts)
+	schema, err := buildSchema(rootField, o, options)
+	if err != nil {
+		return graphql.Schema{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.schemas[schema.QueryType()] = builtSchema{rootField: rootField, options: options}
+	return schema, nil
+}
+
+// Executes the query with the given variables against a schema built by
+// the builder and returns the JSON of the result like QueryStructViaGraphql.
+// The variables replace those of WithVariables.
+func (b *SchemaBuilder) Execute(schema graphql.Schema, query string, variables map[string]any) ([]byte, error) {
+	return b.ExecuteContext(context.Background(), schema, query, variables)
+}
+
+// Executes the query like Execute within the given context, see QueryStructViaGraphqlContext.
+func (b *SchemaBuilder) ExecuteContext(ctx context.Context, schema graphql.Schema, query string, variables map[string]any) ([]byte, error) {
+	b.mu.Lock()
+	built, ok := b.schemas[schema.QueryType()]
+	b.mu.Unlock()
+	if !ok {
+		return nil, errors.New("schema was not built by this builder")
+	}
+
+	// The options of the schema are shared by concurrent queries,
+	// so the state of this query is set on a copy
+	options := *built.options
+	options.ctx = ctx
+	options.variables = variables
+
+	return queryJSON(built.rootField, query, &options, func() (graphql.Schema, error) {
+		return schema, nil
+	})
+}
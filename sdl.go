@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/printer"
+)
+
+// The scalars every GraphQL schema has, which are left out of the SDL.
+var builtinScalars = map[string]bool{
+	"String":  true,
+	"Int":     true,
+	"Float":   true,
+	"Boolean": true,
+	"ID":      true,
+}
+
+// Returns the SDL of the schema that QueryStructViaGraphql builds for o,
+// including the generated arguments like 'where', 'skip' and 'limit':
+//
+//	sdl, err := SchemaSDL("dogs", dogs)
+//
+// The types are sorted by name and their fields and arguments as well,
+// so the output is stable and can be diffed, e.g. in code reviews.
+func SchemaSDL[T any](rootField string, o T, opts ...Option) (string, error) {
+	schema, err := BuildSchema(rootField, o, opts...)
+	if err != nil {
+		return "", err
+	}
+	return PrintSchema(schema), nil
+}
+
+// Prints the schema in the GraphQL schema definition language. The
+// introspection types and the built-in scalars are omitted.
+func PrintSchema(schema graphql.Schema) string {
+	var names []string
+	for name := range schema.TypeMap() {
+		if !strings.HasPrefix(name, "__") && !builtinScalars[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var definitions []ast.Node
+	if query := schema.QueryType(); query != nil {
+		definition := &ast.SchemaDefinition{
+			Kind: "SchemaDefinition",
+			OperationTypes: []*ast.OperationTypeDefinition{{
+				Kind:      "OperationTypeDefinition",
+				Operation: "query",
+				Type:      astNamed(query.Name()),
+			}},
+		}
+		if mutation := schema.MutationType(); mutation != nil {
+			definition.OperationTypes = append(definition.OperationTypes, &ast.OperationTypeDefinition{
+				Kind:      "OperationTypeDefinition",
+				Operation: "mutation",
+				Type:      astNamed(mutation.Name()),
+			})
+		}
+		definitions = append(definitions, definition)
+	}
+	for _, name := range names {
+		if definition := typeDefinition(schema.TypeMap()[name]); definition != nil {
+			definitions = append(definitions, definition)
+		}
+	}
+
+	sdl := printer.Print(&ast.Document{Kind: "Document", Definitions: definitions})
+	return fmt.Sprint(sdl)
+}
+
+// Returns the AST definition of a named type of the schema.
+func typeDefinition(t graphql.Type) ast.Node {
+	switch t := t.(type) {
+	case *graphql.Object:
+		definition := &ast.ObjectDefinition{
+			Kind:        "ObjectDefinition",
+			Name:        astName(t.Name()),
+			Description: astDescription(t.Description()),
+		}
+		for _, field := range t.Fields() {
+			definition.Fields = append(definition.Fields, fieldDefinition(field))
+		}
+		sort.Slice(definition.Fields, func(i, j int) bool {
+			return definition.Fields[i].Name.Value < definition.Fields[j].Name.Value
+		})
+		return definition
+
+	case *graphql.InputObject:
+		definition := &ast.InputObjectDefinition{
+			Kind:        "InputObjectDefinition",
+			Name:        astName(t.Name()),
+			Description: astDescription(t.Description()),
+		}
+		for _, field := range t.Fields() {
+			definition.Fields = append(definition.Fields, &ast.InputValueDefinition{
+				Kind:        "InputValueDefinition",
+				Name:        astName(field.Name()),
+				Description: astDescription(field.Description()),
+				Type:        astType(field.Type),
+			})
+		}
+		sort.Slice(definition.Fields, func(i, j int) bool {
+			return definition.Fields[i].Name.Value < definition.Fields[j].Name.Value
+		})
+		return definition
+
+	case *graphql.Union:
+		definition := &ast.UnionDefinition{
+			Kind:        "UnionDefinition",
+			Name:        astName(t.Name()),
+			Description: astDescription(t.Description()),
+		}
+		for _, member := range t.Types() {
+			definition.Types = append(definition.Types, astNamed(member.Name()))
+		}
+		sort.Slice(definition.Types, func(i, j int) bool {
+			return definition.Types[i].Name.Value < definition.Types[j].Name.Value
+		})
+		return definition
+
+	case *graphql.Enum:
+		// graphql-go keeps the values in a map, so they are sorted as well
+		definition := &ast.EnumDefinition{
+			Kind:        "EnumDefinition",
+			Name:        astName(t.Name()),
+			Description: astDescription(t.Description()),
+		}
+		for _, value := range t.Values() {
+			definition.Values = append(definition.Values, &ast.EnumValueDefinition{
+				Kind: "EnumValueDefinition",
+				Name: astName(value.Name),
+			})
+		}
+		sort.Slice(definition.Values, func(i, j int) bool {
+			return definition.Values[i].Name.Value < definition.Values[j].Name.Value
+		})
+		return definition
+
+	case *graphql.Scalar:
+		return &ast.ScalarDefinition{
+			Kind:        "ScalarDefinition",
+			Name:        astName(t.Name()),
+			Description: astDescription(t.Description()),
+		}
+	}
+	return nil
+}
+
+// Returns the AST definition of a field including its sorted arguments.
+func fieldDefinition(field *graphql.FieldDefinition) *ast.FieldDefinition {
+	definition := &ast.FieldDefinition{
+		Kind:        "FieldDefinition",
+		Name:        astName(field.Name),
+		Description: astDescription(field.Description),
+		Type:        astType(field.Type),
+	}
+	for _, arg := range field.Args {
+		definition.Arguments = append(definition.Arguments, &ast.InputValueDefinition{
+			Kind:        "InputValueDefinition",
+			Name:        astName(arg.Name()),
+			Description: astDescription(arg.Description()),
+			Type:        astType(arg.Type),
+		})
+	}
+	sort.Slice(definition.Arguments, func(i, j int) bool {
+		return definition.Arguments[i].Name.Value < definition.Arguments[j].Name.Value
+	})
+
+	if field.DeprecationReason != "" {
+		definition.Directives = append(definition.Directives, &ast.Directive{
+			Kind: "Directive",
+			Name: astName("deprecated"),
+			Arguments: []*ast.Argument{{
+				Kind:  "Argument",
+				Name:  astName("reason"),
+				Value: &ast.StringValue{Kind: "StringValue", Value: field.DeprecationReason},
+			}},
+		})
+	}
+	return definition
+}
+
+// Returns the AST reference of a type like "[Dog]!".
+func astType(t graphql.Type) ast.Type {
+	switch t := t.(type) {
+	case *graphql.List:
+		return &ast.List{Kind: "List", Type: astType(t.OfType)}
+	case *graphql.NonNull:
+		return &ast.NonNull{Kind: "NonNull", Type: astType(t.OfType)}
+	}
+	return astNamed(t.Name())
+}
+
+func astNamed(name string) *ast.Named {
+	return &ast.Named{Kind: "Named", Name: astName(name)}
+}
+
+func astName(name string) *ast.Name {
+	return &ast.Name{Kind: "Name", Value: name}
+}
+
+func astDescription(description string) *ast.StringValue {
+	if description == "" {
+		return nil
+	}
+	return &ast.StringValue{Kind: "StringValue", Value: description}
+}
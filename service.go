@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/graphql-go/graphql"
+)
+
+// The name of the root field added by WithServiceMetadata.
+const serviceFieldName = "_service"
+
+// Adds a '_service' root field that exposes the given values, e.g. for
+// monitoring tools that check the health of the API:
+//
+//	WithServiceMetadata(map[string]any{
+//		"version": "1.4.0",
+//		"uptime":  func() time.Duration { return time.Since(started) },
+//	})
+//
+//	{ _service { version uptime } }
+//
+// Values can be strings, numbers, bools or time.Time. Functions without
+// parameters that return one of those are called every time the field is resolved.
+func WithServiceMetadata(metadata map[string]any) Option {
+	return func(o *options) {
+		o.serviceMetadata = metadata
+	}
+}
+
+// Creates the '_service' root field from the registered metadata.
+func createServiceField(metadata map[string]any, options *options) (*graphql.Field, error) {
+	fields := graphql.Fields{}
+	for name, value := range metadata {
+		if !graphqlName.MatchString(name) {
+			return nil, fmt.Errorf("service metadata key %q is not a valid GraphQL name", name)
+		}
+
+		t := reflect.TypeOf(value)
+		isFunc := t != nil && t.Kind() == reflect.Func
+		if isFunc {
+			if t.NumIn() != 0 || t.NumOut() != 1 {
+				return nil, fmt.Errorf("service metadata %q must be a function without parameters and a single result", name)
+			}
+			t = t.Out(0)
+		}
+
+		output := metadataOutput(t, options)
+		if output == nil {
+			return nil, fmt.Errorf("service metadata %q has unsupported type %v", name, t)
+		}
+
+		// Value copy to ensure proper capturing of variable in Resolve closure.
+		r := reflect.ValueOf(value)
+		fields[name] = &graphql.Field{
+			Type: output,
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				if isFunc {
+					return resolveValue(r.Call(nil)[0], options)
+				}
+				return resolveValue(r, options)
+			},
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil, errors.New("service metadata must contain at least one value")
+	}
+
+	return &graphql.Field{
+		Type: graphql.NewObject(graphql.ObjectConfig{
+			Name:   "_Service",
+			Fields: fields,
+		}),
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			// The fields resolve from the metadata captured above
+			return struct{}{}, nil
+		},
+	}, nil
+}
+
+func metadataOutput(t reflect.Type, options *options) graphql.Output {
+	if t == nil {
+		return nil
+	}
+	if t == typeTime {
+		return options.timeOutput()
+	}
+	if t == typeDuration {
+		return graphql.String
+	}
+	return options.basicOutput(t)
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Executes a subscription against the channel fields of the struct o and
+// returns the JSON of one result per value received from the channel:
+//
+//	type Kennel struct {
+//		Dogs     []Dog
+//		Arrivals <-chan Dog
+//	}
+//
+//	results, err := SubscribeStructViaGraphql(ctx, "kennel", kennel, `subscription { arrivals { name } }`)
+//	for b := range results {
+//		...
+//	}
+//
+// Channel fields of o, and function fields returning a channel, are the
+// fields of the subscription root. They stay invisible to queries, which
+// select the other fields under the root field like QueryStructViaGraphql.
+// The returned channel is closed once the source channel is closed or ctx
+// is canceled. Results with errors, e.g. of an invalid subscription, are
+// sent like other results with their 'errors'. A value received from a
+// channel is only seen by one subscription, since channels don't broadcast.
+func SubscribeStructViaGraphql[T any](ctx context.Context, rootField string, o T, query string, opts ...Option) (<-chan []byte, error) {
+	options := newOptions(opts)
+	options.ctx = ctx
+	options.subscriptions = true
+
+	schema, err := buildSchema(rootField, o, options)
+	if err != nil {
+		return nil, err
+	}
+
+	results := graphql.Subscribe(graphql.Params{
+		Schema:         schema,
+		RequestString:  query,
+		VariableValues: options.variables,
+		Context:        ctx,
+	})
+
+	out := make(chan []byte)
+	go func() {
+		defer close(out)
+		for result := range results {
+			b, err := options.marshalResult(result)
+			if err != nil {
+				options.logf("graphql: dropping subscription result: %v", err)
+				continue
+			}
+
+			select {
+			case out <- b:
+			case <-ctx.Done():
+				// graphql-go stops once it sees the canceled context,
+				// until then its pending results are discarded
+				for range results {
+				}
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Creates the subscription root from the channel fields of the struct type
+// t and the function fields returning a channel. load returns the struct.
+func createSubscriptionObject(t reflect.Type, load func() (any, error), typesMap map[string]Pair[graphql.Output, graphql.Fields], filterMap map[string]graphql.ArgumentConfig, options *options) (*graphql.Object, error) {
+	t = indirectType(t)
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("subscriptions require a struct, got %s", t)
+	}
+
+	fields := graphql.Fields{}
+	for _, structField := range reflect.VisibleFields(t) {
+		name, visible := graphqlFieldName(structField)
+		if !structField.IsExported() || !visible {
+			continue
+		}
+
+		chanType := structField.Type
+		var signature funcSignature
+		isFunc := chanType.Kind() == reflect.Func
+		if isFunc {
+			var err error
+			signature, err = parseFuncSignature(chanType, t)
+			if err != nil {
+				continue
+			}
+			chanType = chanType.Out(0)
+		}
+		if chanType.Kind() != reflect.Chan || chanType.ChanDir()&reflect.RecvDir == 0 {
+			continue
+		}
+
+		output, _, err := createGraphQlFieldHierarchy(chanType.Elem(), []string{name}, typesMap, filterMap, options)
+		if err != nil {
+			return nil, err
+		}
+		if output == nil {
+			options.logf("graphql: skipping subscription %s of %s: value type %s is not supported", structField.Name, t.Name(), chanType.Elem())
+			continue
+		}
+
+		// Channels of lists take the same arguments as list fields
+		args, err := createFieldArguments(name, chanType.Elem(), nil, filterMap, options)
+		if err != nil {
+			return nil, err
+		}
+		funcArgs, err := signature.createArguments(options)
+		if err != nil {
+			return nil, fmt.Errorf("subscription %s of %s: %w", structField.Name, t.Name(), err)
+		}
+		for argName, arg := range funcArgs {
+			args[argName] = arg
+		}
+
+		// Value copy to ensure proper capturing of variable in closures.
+		reflectedField := structField
+		fields[name] = &graphql.Field{
+			Name: structField.Name,
+			Type: output,
+			Args: args,
+			Subscribe: func(p graphql.ResolveParams) (any, error) {
+				o, err := load()
+				if err != nil {
+					return nil, err
+				}
+				root, ok := indirectValue(reflect.ValueOf(o))
+				if !ok {
+					return nil, fmt.Errorf("subscription %s of a nil %s", reflectedField.Name, t.Name())
+				}
+
+				r, err := options.fieldValue(root, reflectedField)
+				if err != nil {
+					return nil, err
+				}
+				if isFunc && r.IsValid() && !r.IsNil() {
+					p.Source = root.Interface()
+					if r, err = signature.call(r, p); err != nil {
+						return nil, err
+					}
+				}
+				if !r.IsValid() || r.IsNil() {
+					return nil, fmt.Errorf("channel of subscription %s is nil", reflectedField.Name)
+				}
+				return forwardChannel(p.Context, r), nil
+			},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				// The source is the value received from the channel
+				if p.Source == nil {
+					return nil, nil
+				}
+				p.Args = omitArguments(p.Args, funcArgs)
+				return resolveFieldValue(reflect.ValueOf(p.Source), p, reflectedField.Name, options)
+			},
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%s has no channel fields to expose as subscriptions", t.Name())
+	}
+	return graphql.NewObject(graphql.ObjectConfig{Name: "RootSubscription", Fields: fields}), nil
+}
+
+// Forwards the values received from the channel r to the channel graphql-go
+// expects from subscriptions, until r is closed or the context is canceled.
+func forwardChannel(ctx context.Context, r reflect.Value) chan any {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	events := make(chan any)
+	go func() {
+		defer close(events)
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+			{Dir: reflect.SelectRecv, Chan: r},
+		}
+		for {
+			chosen, value, ok := reflect.Select(cases)
+			if chosen == 0 || !ok {
+				return
+			}
+
+			select {
+			case events <- value.Interface():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events
+}
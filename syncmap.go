@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var typeSyncMapPointer = reflect.TypeOf(&sync.Map{})
+
+// Identifies a *sync.Map field of a struct type
+type syncMapField struct {
+	owner reflect.Type
+	field string
+}
+
+// Exposes the *sync.Map field of the owner struct as a read-only list of key/value
+// objects, like a map field of type mapType. Since sync.Map is untyped, mapType
+// declares the types of its keys and values:
+//
+//	type Kennel struct {
+//		Cache *sync.Map // Holds string keys and Dog values
+//	}
+//
+//	WithSyncMap(reflect.TypeOf(Kennel{}), "Cache", reflect.TypeOf(map[string]Dog{}))
+//
+// Only pointer fields are supported, a sync.Map must not be copied after first use.
+func WithSyncMap(owner reflect.Type, field string, mapType reflect.Type) Option {
+	return func(o *options) {
+		if o.syncMaps == nil {
+			o.syncMaps = map[syncMapField]reflect.Type{}
+		}
+		o.syncMaps[syncMapField{owner: owner, field: field}] = mapType
+	}
+}
+
+// Copies the entries of a *sync.Map into a new map of the registered map type.
+func syncMapValue(r reflect.Value, mapType reflect.Type) (reflect.Value, error) {
+	if r.IsNil() {
+		return reflect.Zero(mapType), nil
+	}
+
+	var err error
+	m := reflect.MakeMap(mapType)
+	r.Interface().(*sync.Map).Range(func(key, value any) bool {
+		k, v := reflect.ValueOf(key), reflect.ValueOf(value)
+		if !k.IsValid() || !k.Type().AssignableTo(mapType.Key()) || !v.IsValid() || !v.Type().AssignableTo(mapType.Elem()) {
+			err = fmt.Errorf("sync.Map entry %v: %v doesn't match the registered type %s", key, value, mapType)
+			return false
+		}
+
+		m.SetMapIndex(k, v)
+		return true
+	})
+	return m, err
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Options of the 'graphql' struct tag that are recognized when they are
+// written without a leading comma, e.g. `graphql:"rune"` instead of `graphql:",rune"`.
+var tagOptions = map[string]bool{
+	"rune":          true,
+	"bytesAsString": true,
+	"deprecated":    true,
+	"nonnull":       true,
+	"blob":          true,
+	"filterOnly":    true,
+	"outputOnly":    true,
+	"paginated":     true,
+	"connection":    true,
+}
+
+// The parsed 'graphql' struct tag of a field.
+// The tag is a comma separated list of options:
+// Rune rune `graphql:"rune"`
+type fieldTag struct {
+	name    string
+	options map[string]string
+}
+
+func parseFieldTag(field reflect.StructField) fieldTag {
+	tag := fieldTag{options: map[string]string{}}
+
+	parts := strings.Split(field.Tag.Get("graphql"), ",")
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		key, value, _ := strings.Cut(part, "=")
+		if i == 0 && !tagOptions[key] && !strings.Contains(part, "=") {
+			tag.name = part
+			continue
+		}
+		tag.options[key] = value
+	}
+	return tag
+}
+
+// Returns the GraphQL name of a struct field and true, or false if the field is
+// hidden with `graphql:"-"` or `json:"-"`. The name of the graphql tag takes
+// precedence, e.g. 'fullName' for `graphql:"fullName" json:"full_name"`, so
+// GraphQL names can differ from JSON names. Otherwise the name of the json
+// tag is used like in encoding/json, e.g. 'full_name' for
+// `json:"full_name,omitempty"`. Other fields use their lowercased Go name.
+func graphqlFieldName(field reflect.StructField) (string, bool) {
+	switch tagName := parseFieldTag(field).name; tagName {
+	case "-":
+		return "", false
+	case "":
+		// Named like in encoding/json
+	default:
+		return tagName, true
+	}
+
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name, true
+	}
+	return strings.ToLower(field.Name), true
+}
+
+// Returns true if encoding/json encodes the field of t as a field of its own.
+// Embedded structs are flattened into t instead, so their fields are promoted,
+// unless the json or graphql tag names the embedded struct. Then its fields
+// aren't promoted.
+func isJSONField(t reflect.Type, field reflect.StructField) bool {
+	if isFlattened(field) {
+		return false
+	}
+	for _, i := range field.Index[:len(field.Index)-1] {
+		embedded := t.Field(i)
+		if _, visible := graphqlFieldName(embedded); !visible || !isFlattened(embedded) {
+			return false
+		}
+		t = indirectType(embedded.Type)
+	}
+	return true
+}
+
+// Returns true for embedded structs and pointers to structs without a json or graphql name.
+func isFlattened(field reflect.StructField) bool {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	return field.Anonymous && indirectType(field.Type).Kind() == reflect.Struct && name == "" && parseFieldTag(field).name == ""
+}
+
+// Returns the description of a field from its 'graphql_desc' tag, which shows
+// up in introspection, e.g. in GraphiQL:
+// Name string `graphql_desc:"The display name of the animal"`
+func fieldDescription(field reflect.StructField) string {
+	return field.Tag.Get("graphql_desc")
+}
+
+// Returns the description of a struct type from the 'graphql_desc' tag of a
+// blank field, since Go types can't be tagged themselves:
+//
+//	type Dog struct {
+//		_    struct{} `graphql_desc:"A dog of the kennel"`
+//		Name string
+//	}
+func typeDescription(t reflect.Type) string {
+	for i := 0; i < t.NumField(); i++ {
+		if field := t.Field(i); field.Name == "_" {
+			if description := fieldDescription(field); description != "" {
+				return description
+			}
+		}
+	}
+	return ""
+}
+
+// Returns true if the json tag of the field has the given option, e.g. omitempty.
+func hasJSONOption(field reflect.StructField, option string) bool {
+	_, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+	for _, o := range strings.Split(options, ",") {
+		if strings.TrimSpace(o) == option {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true for the values encoding/json omits with omitempty: false, 0,
+// nil pointers and interfaces and empty strings, arrays, slices and maps.
+// Structs are never empty, including time.Time.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
+
+// Returns true if the option is set in the tag.
+func (t fieldTag) has(option string) bool {
+	_, ok := t.options[option]
+	return ok
+}
+
+// Returns the reason of a field tagged with `graphql:"deprecated=reason"`.
+// Since options are separated by commas, the reason can't contain any.
+func (t fieldTag) deprecationReason() string {
+	reason, ok := t.options["deprecated"]
+	if !ok {
+		return ""
+	}
+
+	if reason == "" {
+		// Default reason of the GraphQL specification
+		return graphql.DefaultDeprecationReason
+	}
+	return reason
+}
+
+// Returns the GraphQL type a tag option overrides the default type of the field with,
+// or nil if the tag doesn't change the type.
+func taggedOutput(field reflect.StructField, tag fieldTag) graphql.Output {
+	switch {
+	case tag.has("rune") && field.Type.Kind() == reflect.Int32:
+		// 'rune' is an alias of 'int32' and can't be told apart by reflection.
+		return runeScalar
+	case tag.has("bytesAsString") && isByteSequence(field.Type):
+		// Bytes that hold UTF-8 text instead of binary data
+		return graphql.String
+	case tag.options["type"] == "ID" && isIDKind(field.Type.Kind()):
+		// Integer IDs are serialized as strings by convention
+		return graphql.ID
+	case hasJSONOption(field, "string") && isNumberKind(field.Type.Kind()):
+		// Numbers encoded as strings by encoding/json, e.g. `json:"id,string"`
+		return graphql.String
+	case tag.options["bool"] == "int" && field.Type.Kind() == reflect.Bool:
+		// Legacy clients that expect booleans as 0 and 1
+		return boolIntScalar
+	case tag.options["bool"] == "yesno" && field.Type.Kind() == reflect.Bool:
+		// Legacy clients that expect booleans as "yes" and "no"
+		return boolYesNoScalar
+	}
+	return nil
+}
+
+// Resolves the value of a field whose type was overridden by taggedOutput.
+func resolveTagged(r reflect.Value, output graphql.Output) any {
+	switch output {
+	case runeScalar:
+		// Serialized by the Rune scalar
+		return rune(r.Int())
+	case graphql.String:
+		if isByteSequence(r.Type()) {
+			return string(byteSequence(r))
+		}
+		return numberString(r)
+	case graphql.ID:
+		return idString(r)
+	case boolIntScalar, boolYesNoScalar:
+		// Serialized by the scalar
+		return r.Bool()
+	}
+	return nil
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// Formats an integer or float like encoding/json does for fields tagged with
+// `json:",string"`. Named types are formatted by their value, not by their
+// MarshalJSON or String method.
+func numberString(r reflect.Value) string {
+	if !r.CanFloat() {
+		return idString(r)
+	}
+
+	var f any = r.Float()
+	if r.Type().Bits() == 32 {
+		f = float32(r.Float())
+	}
+	b, err := json.Marshal(f)
+	if err != nil {
+		// NaN and infinities, which encoding/json rejects
+		return strconv.FormatFloat(r.Float(), 'g', -1, r.Type().Bits())
+	}
+	return string(b)
+}
+
+func isIDKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.String:
+		return true
+	}
+	return false
+}
+
+// Formats an integer or string ID field. Named types with a String
+// method are formatted by their value, not by the method.
+func idString(r reflect.Value) string {
+	switch {
+	case r.CanInt():
+		return strconv.FormatInt(r.Int(), 10)
+	case r.CanUint():
+		return strconv.FormatUint(r.Uint(), 10)
+	}
+	return r.String()
+}
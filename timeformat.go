@@ -0,0 +1,48 @@
+package main
+
+import (
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Decides how time.Time values appear in results.
+type TimeFormat int
+
+const (
+	// Milliseconds since the Unix epoch as Float.
+	TimeUnixMillis TimeFormat = iota
+	// Seconds since the Unix epoch as Float.
+	TimeUnixSeconds
+	// RFC 3339 strings like "2024-01-31T12:00:00Z" as String.
+	TimeRFC3339
+)
+
+// Sets how time.Time fields are exposed. Defaults to TimeUnixMillis.
+// The bounds of 'where' filters of time lists are still given as
+// RFC 3339 strings or Unix milliseconds, see timeRangeInput.
+func WithTimeFormat(format TimeFormat) Option {
+	return func(o *options) {
+		o.timeFormat = format
+	}
+}
+
+// Returns the output type of time.Time values.
+func (o *options) timeOutput() graphql.Output {
+	if o.timeFormat == TimeRFC3339 {
+		return graphql.String
+	}
+	// Float due to the 32-bit limitations of ints
+	return graphql.Float
+}
+
+// Converts t into the representation of the output type from timeOutput.
+func (o *options) formatTime(t time.Time) any {
+	switch o.timeFormat {
+	case TimeUnixSeconds:
+		return float64(t.Unix())
+	case TimeRFC3339:
+		return t.Format(time.RFC3339)
+	}
+	return float64(t.UnixMilli())
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// A point in time given as an RFC 3339 string or as Unix milliseconds,
+// the representation of time.Time in results, see getBasicOutput.
+var timeScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "Time",
+	Description: "The `Time` scalar type represents a point in time as an RFC 3339 string or as milliseconds since the Unix epoch.",
+	Serialize: func(value any) any {
+		if t, ok := value.(time.Time); ok {
+			return float64(t.UnixMilli())
+		}
+		return nil
+	},
+	ParseValue: func(value any) any {
+		switch value := value.(type) {
+		case string:
+			return parseTime(value)
+		case float64:
+			return time.UnixMilli(int64(value))
+		case int:
+			return time.UnixMilli(int64(value))
+		}
+		return nil
+	},
+	ParseLiteral: func(valueAST ast.Value) any {
+		switch value := valueAST.(type) {
+		case *ast.StringValue:
+			return parseTime(value.Value)
+		case *ast.IntValue:
+			return parseUnixMilli(value.Value)
+		case *ast.FloatValue:
+			return parseUnixMilli(value.Value)
+		}
+		return nil
+	},
+})
+
+// The 'where' argument of lists of time.Time, which keeps the elements
+// within the given bounds:
+// timestamps(where: {gte: "2024-01-01T00:00:00Z", lt: 1735689600000})
+var timeRangeInput = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "TimeRange",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"gt":  &graphql.InputObjectFieldConfig{Type: timeScalar},
+		"gte": &graphql.InputObjectFieldConfig{Type: timeScalar},
+		"lt":  &graphql.InputObjectFieldConfig{Type: timeScalar},
+		"lte": &graphql.InputObjectFieldConfig{Type: timeScalar},
+	},
+})
+
+// Returns the parsed RFC 3339 time, or nil if it is invalid.
+func parseTime(s string) any {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return nil
+	}
+	return t
+}
+
+// Returns the time of the Unix milliseconds, or nil if they are invalid.
+func parseUnixMilli(s string) any {
+	ms, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil
+	}
+	return time.UnixMilli(int64(ms))
+}
+
+// Returns the elements of the time.Time list or array r that are within
+// all bounds of the range as a new slice. A nil range keeps all elements.
+func filterTimes(r reflect.Value, timeRange map[string]any) reflect.Value {
+	matches := reflect.MakeSlice(reflect.SliceOf(typeTime), 0, r.Len())
+	for i := 0; i < r.Len(); i++ {
+		if inTimeRange(r.Index(i).Interface().(time.Time), timeRange) {
+			matches = reflect.Append(matches, r.Index(i))
+		}
+	}
+	return matches
+}
+
+func inTimeRange(t time.Time, timeRange map[string]any) bool {
+	for bound, value := range timeRange {
+		b, ok := value.(time.Time)
+		if !ok {
+			continue
+		}
+
+		switch bound {
+		case "gt":
+			if !t.After(b) {
+				return false
+			}
+		case "gte":
+			if t.Before(b) {
+				return false
+			}
+		case "lt":
+			if !t.Before(b) {
+				return false
+			}
+		case "lte":
+			if t.After(b) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Converts a list of time.Time into the configured time format, since
+// graphql-go serializes list elements without a resolver, see resolveValue.
+func timestamps(r reflect.Value, options *options) []any {
+	values := make([]any, r.Len())
+	for i := range values {
+		values[i] = options.formatTime(r.Index(i).Interface().(time.Time))
+	}
+	return values
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Names the GraphQL object of the struct type t, e.g. to tell apart
+// structs of the same name from different packages:
+//
+//	WithTypeName(reflect.TypeOf(billing.Account{}), "BillingAccount")
+//
+// The names derived from the type name follow, e.g. 'BillingAccountPage'.
+// Structs are named after their Go type by default, anonymous structs after
+// the path of fields leading to them, e.g. 'DogsOwner' for the Owner field
+// of a root field named dogs. Building the schema fails for two types with
+// the same name and for names that aren't valid GraphQL names.
+func WithTypeName(t reflect.Type, name string) Option {
+	return func(o *options) {
+		if o.typeNames == nil {
+			o.typeNames = map[reflect.Type]string{}
+		}
+		o.typeNames[t] = name
+	}
+}
+
+// Returns the name of the object of the struct type t, which is reached
+// by the given path. The names of anonymous structs are remembered, so
+// the same anonymous struct reached by another path is the same object.
+func (o *options) objectName(t reflect.Type, path []string) string {
+	if name, ok := o.typeNames[t]; ok {
+		return name
+	}
+	if t.Name() != "" {
+		return t.Name()
+	}
+
+	var name strings.Builder
+	for _, part := range path {
+		if part != "" {
+			name.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+
+	if o.typeNames == nil {
+		o.typeNames = map[reflect.Type]string{}
+	}
+	o.typeNames[t] = name.String()
+	return name.String()
+}
+
+// Reserves the GraphQL type name for the Go type t. Returns an error if the
+// name isn't valid or another type has it, e.g. a type of the same name from
+// another package, which would otherwise be exposed as the first type.
+func (o *options) claimTypeName(name string, t reflect.Type) error {
+	if !graphqlName.MatchString(name) {
+		return fmt.Errorf("type name %q of %s is not a valid GraphQL name", name, qualifiedTypeName(t))
+	}
+	if err := o.checkTypeName(name, t); err != nil {
+		return err
+	}
+
+	if o.typeOwners == nil {
+		o.typeOwners = map[string]reflect.Type{}
+	}
+	o.typeOwners[name] = t
+	return nil
+}
+
+// Returns an error if the GraphQL type name is claimed by another Go type than t.
+func (o *options) checkTypeName(name string, t reflect.Type) error {
+	if owner, ok := o.typeOwners[name]; ok && owner != t {
+		return fmt.Errorf("types %s and %s are both named %s, rename one with WithTypeName", qualifiedTypeName(owner), qualifiedTypeName(t), name)
+	}
+	return nil
+}
+
+// Returns the name of the type including its package path,
+// e.g. 'example.com/billing.Account'.
+func qualifiedTypeName(t reflect.Type) string {
+	if t.Name() == "" || t.PkgPath() == "" {
+		return t.String()
+	}
+	return t.PkgPath() + "." + t.Name()
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Exposes fields of the interface type iface as a GraphQL union of the given struct
+// types. The member type is picked from the dynamic value of the field at runtime:
+//
+//	type Pet interface{}
+//
+//	type Owner struct {
+//		Pet Pet // Holds a Cat or a Dog
+//	}
+//
+//	WithUnion(reflect.TypeOf((*Pet)(nil)).Elem(), reflect.TypeOf(Cat{}), reflect.TypeOf(Dog{}))
+//
+// Function fields may return the interface type as well. The union is named after
+// the interface type, or after its members ("CatOrDog") if the interface is unnamed.
+func WithUnion(iface reflect.Type, members ...reflect.Type) Option {
+	return func(o *options) {
+		if o.unions == nil {
+			o.unions = map[reflect.Type][]reflect.Type{}
+		}
+		o.unions[iface] = members
+	}
+}
+
+func (o *options) unionTypeName(iface reflect.Type, members []reflect.Type) string {
+	if iface.Name() != "" {
+		return iface.Name()
+	}
+
+	names := make([]string, len(members))
+	for i, member := range members {
+		names[i] = o.typeNamePart(member)
+	}
+	return strings.Join(names, "Or")
+}
+
+func createUnion(iface reflect.Type, members []reflect.Type, path []string, typesMap map[string]Pair[graphql.Output, graphql.Fields], filterMap map[string]graphql.ArgumentConfig, options *options) (graphql.Output, graphql.Fields, error) {
+	if iface.Kind() != reflect.Interface {
+		return nil, nil, fmt.Errorf("union type %s must be an interface", iface)
+	}
+
+	name := options.unionTypeName(iface, members)
+	knownType, ok := typesMap[name]
+	if ok {
+		return knownType.First, nil, nil
+	}
+
+	objects := map[reflect.Type]*graphql.Object{}
+	types := make([]*graphql.Object, 0, len(members))
+	for _, member := range members {
+		if member.Kind() != reflect.Struct || !member.Implements(iface) {
+			return nil, nil, fmt.Errorf("union member %s must be a struct implementing %s", member, iface)
+		}
+
+		output, _, err := createGraphQlFieldHierarchy(member, path, typesMap, filterMap, options)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		object, ok := output.(*graphql.Object)
+		if !ok {
+			return nil, nil, fmt.Errorf("union member %s is not exposed as an object", member)
+		}
+
+		objects[member] = object
+		types = append(types, object)
+	}
+
+	u := graphql.NewUnion(graphql.UnionConfig{
+		Name:  name,
+		Types: types,
+		ResolveType: func(p graphql.ResolveTypeParams) *graphql.Object {
+			t := reflect.TypeOf(p.Value)
+			for t != nil && t.Kind() == reflect.Pointer {
+				t = t.Elem()
+			}
+			return objects[t]
+		},
+	})
+
+	typesMap[name] = Pair[graphql.Output, graphql.Fields]{First: u}
+
+	return u, nil, nil
+}